@@ -5,14 +5,21 @@ import (
 
 	"discord-bot/commands"
 	"discord-bot/config"
+	"discord-bot/data"
+	"discord-bot/notify"
 
 	"github.com/bwmarrin/discordgo"
 )
 
 // Bot represents the Discord bot
 type Bot struct {
-	Session *discordgo.Session
-	Config  *config.Config
+	Session   *discordgo.Session
+	Config    *config.Config
+	Notifier  *notify.Dispatcher
+	Outbox    *notify.Outbox
+	Callbacks *notify.CallbackServer
+
+	stopSweeper chan struct{}
 }
 
 // New creates a new bot instance
@@ -24,8 +31,19 @@ func New(cfg *config.Config) (*Bot, error) {
 	}
 
 	bot := &Bot{
-		Session: dg,
-		Config:  cfg,
+		Session:  dg,
+		Config:   cfg,
+		Notifier: notify.NewDispatcher(),
+	}
+
+	// Register notification backends
+	bot.Notifier.Register(data.DeliveryNTFY, notify.NewNTFY(cfg.NTFYBaseURL))
+	bot.Notifier.Register(data.DeliveryDiscord, notify.NewDiscordDM(dg))
+	bot.Notifier.Register(data.DeliveryWebhook, notify.NewWebhook())
+
+	// Receive NTFY action button presses if a callback address is configured
+	if cfg.CallbackListenAddr != "" {
+		bot.Callbacks = notify.NewCallbackServer(cfg.CallbackListenAddr, cfg.CallbackPublicURL)
 	}
 
 	// Register event handlers
@@ -35,6 +53,11 @@ func New(cfg *config.Config) (*Bot, error) {
 
 	// Set required intents for slash commands and voice state updates
 	dg.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildVoiceStates
+	if cfg.PresenceIntent {
+		// Privileged, connecting fails unless it's enabled in the developer portal
+		dg.Identify.Intents |= discordgo.IntentsGuildPresences
+	}
+	commands.PresenceEnabled = cfg.PresenceIntent
 
 	// Initialize commands
 	commands.Initialize()
@@ -44,6 +67,14 @@ func New(cfg *config.Config) (*Bot, error) {
 
 // Start starts the bot
 func (b *Bot) Start() error {
+	// Set up LFG handling before events start arriving
+	b.initLFG()
+
+	if b.Callbacks != nil {
+		b.Callbacks.Start()
+	}
+	b.startSweeper()
+
 	err := b.Session.Open()
 	if err != nil {
 		return fmt.Errorf("error opening connection: %v", err)
@@ -60,6 +91,12 @@ func (b *Bot) Start() error {
 
 // Stop stops the bot
 func (b *Bot) Stop() {
+	if b.stopSweeper != nil {
+		close(b.stopSweeper)
+	}
+	if b.Callbacks != nil {
+		b.Callbacks.Stop()
+	}
 	b.Session.Close()
 }
 
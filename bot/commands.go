@@ -9,13 +9,55 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
-// interactionCreate handles slash command interactions
+// interactionCreate handles slash command, autocomplete and message component interactions
 func (b *Bot) interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	// Only handle slash commands
-	if i.Type != discordgo.InteractionApplicationCommand {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		b.handleSlashCommand(s, i)
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		b.handleAutocomplete(s, i)
+	case discordgo.InteractionMessageComponent:
+		b.handleComponent(s, i)
+	}
+}
+
+// handleAutocomplete asks the command for suggestions while an option is being typed
+func (b *Bot) handleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	command, exists := commands.Get(i.ApplicationCommandData().Name)
+	if !exists || command.Autocomplete == nil {
+		// Answer anyway so Discord doesn't show "loading options failed"
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+			Data: &discordgo.InteractionResponseData{
+				Choices: []*discordgo.ApplicationCommandOptionChoice{},
+			},
+		})
 		return
 	}
 
+	command.Autocomplete(s, i)
+}
+
+// handleComponent routes button presses to the command that created them
+func (b *Bot) handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	handler, args, exists := commands.GetComponent(i.MessageComponentData().CustomID)
+	if !exists {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "This button no longer works.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	handler(s, i, args)
+}
+
+// handleSlashCommand runs a slash command
+func (b *Bot) handleSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+
 	commandName := i.ApplicationCommandData().Name
 
 	// Look up the command
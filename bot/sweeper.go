@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"discord-bot/commands"
+)
+
+// sweepInterval is how often expired data is cleaned up
+const sweepInterval = time.Minute
+
+// startSweeper periodically removes data that has expired
+func (b *Bot) startSweeper() {
+	b.stopSweeper = make(chan struct{})
+	ticker := time.NewTicker(sweepInterval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-b.stopSweeper:
+				return
+			case now := <-ticker.C:
+				b.sweep(now)
+			}
+		}
+	}()
+}
+
+// sweep runs a single cleanup pass
+func (b *Bot) sweep(now time.Time) {
+	// Drop subscriptions whose NTFY topic was never verified
+	expired, err := commands.SubManager.RemoveExpiredPending(now)
+	if err != nil {
+		log.Printf("Error removing unverified subscriptions: %v", err)
+	}
+	for _, sub := range expired {
+		fmt.Printf("⌛ Removed unverified %s subscription for %s\n", sub.Game, sub.Username)
+	}
+
+	// Drop time-limited subscriptions that have run out and offer to renew them
+	lapsed, err := commands.SubManager.RemoveExpired(now)
+	if err != nil {
+		log.Printf("Error removing expired subscriptions: %v", err)
+	}
+	for _, sub := range lapsed {
+		fmt.Printf("⌛ %s subscription for %s to %s expired\n", sub.Duration, sub.Username, sub.Game)
+		if err := commands.SendLapsedNotice(b.Session, sub); err != nil {
+			log.Printf("Error telling %s their subscription expired: %v", sub.Username, err)
+		}
+	}
+}
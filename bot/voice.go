@@ -3,7 +3,9 @@ package bot
 import (
 	"log"
 
+	"discord-bot/commands"
 	"discord-bot/lfg"
+	"discord-bot/notify"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -13,16 +15,29 @@ var lfgManager *lfg.Manager
 
 // initLFG initializes the LFG manager
 func (b *Bot) initLFG() {
-	lfgManager = lfg.New(b.Config)
+	lfgManager = lfg.New(b.Config, lfg.Dependencies{
+		Subscriptions: commands.SubManager,
+		Profiles:      commands.Profiles,
+		History:       commands.History,
+		Templates:     commands.Templates,
+		Guilds:        commands.Guilds,
+		Games:         commands.Games,
+		Outbox:        b.Outbox,
+		Callbacks:     b.Callbacks,
+	})
+	commands.LFGStats = lfgManager.Stats
+	commands.Throttle = lfgManager.Throttle
+	commands.Callbacks = b.Callbacks
+
+	if b.Callbacks != nil {
+		b.Callbacks.OnAccept = func(cb notify.Callback) {
+			lfgManager.HandleAccepted(b.Session, cb)
+		}
+	}
 }
 
 // voiceStateUpdate handles voice state changes (join/leave voice channels)
 func (b *Bot) voiceStateUpdate(s *discordgo.Session, vs *discordgo.VoiceStateUpdate) {
-	// Initialize LFG manager if not already done
-	if lfgManager == nil {
-		b.initLFG()
-	}
-
 	// Ignore bot's own voice state changes
 	if vs.UserID == s.State.User.ID {
 		return
@@ -32,6 +47,24 @@ func (b *Bot) voiceStateUpdate(s *discordgo.Session, vs *discordgo.VoiceStateUpd
 	if vs.ChannelID != "" && (vs.BeforeUpdate == nil || vs.BeforeUpdate.ChannelID != vs.ChannelID) {
 		b.handleUserJoinedVoice(s, vs)
 	}
+
+	// Check if user left a voice channel (or moved to another one)
+	if vs.BeforeUpdate != nil && vs.BeforeUpdate.ChannelID != "" && vs.BeforeUpdate.ChannelID != vs.ChannelID {
+		b.handleUserLeftVoice(s, vs.BeforeUpdate.ChannelID)
+	}
+}
+
+// handleUserLeftVoice processes when a user leaves a voice channel
+func (b *Bot) handleUserLeftVoice(s *discordgo.Session, channelID string) {
+	channel, err := s.Channel(channelID)
+	if err != nil {
+		log.Printf("Error getting channel info: %v", err)
+		return
+	}
+
+	if lfgManager.IsLFGChannel(channel) {
+		lfgManager.HandleUserLeftLFG(s, channel)
+	}
 }
 
 // handleUserJoinedVoice processes when a user joins a voice channel
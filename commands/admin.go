@@ -0,0 +1,402 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"discord-bot/data"
+	"discord-bot/lfg"
+	"discord-bot/notify"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Global notification outbox - initialized in main
+var Outbox *notify.Outbox
+
+// LFG notification stats - set when the bot sets up LFG handling
+var LFGStats *lfg.Stats
+
+// Global message template manager - initialized in main
+var Templates *data.TemplateManager
+
+// Global guild settings manager - initialized in main
+var Guilds *data.GuildManager
+
+// adminPermission restricts admin commands to server administrators
+var adminPermission int64 = discordgo.PermissionAdministrator
+
+const (
+	// templateEchoLength is how much of a changed template is shown back
+	templateEchoLength = 1500
+
+	// templatePreviewLength is how much of each template /admin template show
+	// lists, so all of them fit in one message
+	templatePreviewLength = 280
+)
+
+// RegisterAdmin registers the admin slash command
+func RegisterAdmin() {
+	templateChoices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(data.DefaultTemplates))
+	for _, name := range data.TemplateNames() {
+		templateChoices = append(templateChoices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  name,
+			Value: name,
+		})
+	}
+
+	Register(&SlashCommand{
+		Definition: &discordgo.ApplicationCommand{
+			Name:                     "admin",
+			Description:              "Server administration tools",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "outbox",
+					Description: "Show this server's notification delivery queue",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "stats",
+					Description: "Show how many of this server's LFG notifications were sent or suppressed",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "lfg",
+					Description: "Show or change which channels this server uses for LFG",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "voice-channel",
+							Description:  "Voice channel people join to look for a game",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "announcement-channel",
+							Description:  "Text channel LFG announcements are posted in",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "export-subscriptions",
+					Description: "Download this server's subscriptions as a file",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "format",
+							Description: "File format (default: JSON)",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "JSON", Value: "json"},
+								{Name: "CSV", Value: "csv"},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "import-subscriptions",
+					Description: "Import subscriptions from an exported JSON or CSV file",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionAttachment,
+							Name:        "file",
+							Description: "The JSON or CSV file to import",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "mode",
+							Description: "Keep subscriptions missing from the file or remove them (default: keep)",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Merge - keep the rest", Value: "merge"},
+								{Name: "Replace - remove the rest", Value: "replace"},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "game",
+					Description: "Manage the games people can subscribe to",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "list",
+							Description: "Show every game in the catalog",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "add",
+							Description: "Add a game",
+							Options:     gameOptions(true),
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "edit",
+							Description: "Change a game, only the options you give are changed",
+							Options:     gameOptions(false),
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "remove",
+							Description: "Remove a game, existing subscriptions are kept",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:         discordgo.ApplicationCommandOptionString,
+									Name:         "id",
+									Description:  "The game's ID, e.g. rocket-league",
+									Required:     true,
+									Autocomplete: true,
+								},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "template",
+					Description: "Customize the bot's messages for this server",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "show",
+							Description: "Show the message templates and available variables",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "set",
+							Description: "Set a message template (use \\n for new lines)",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionString,
+									Name:        "name",
+									Description: "The message to customize",
+									Required:    true,
+									Choices:     templateChoices,
+								},
+								{
+									Type:        discordgo.ApplicationCommandOptionString,
+									Name:        "template",
+									Description: "Go text/template, e.g. {{.User}} wants to play {{.Game}}!",
+									Required:    true,
+								},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "reset",
+							Description: "Go back to the default message",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionString,
+									Name:        "name",
+									Description: "The message to reset",
+									Required:    true,
+									Choices:     templateChoices,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Handler:      handleAdmin,
+		Autocomplete: autocompleteGames, // Only game IDs are autocompleted
+	})
+
+	RegisterComponent("import", handleImportButton)
+}
+
+// handleAdmin dispatches admin subcommands
+func handleAdmin(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	subcommand := i.ApplicationCommandData().Options[0]
+
+	switch subcommand.Name {
+	case "outbox":
+		handleAdminOutbox(s, i)
+	case "stats":
+		handleAdminStats(s, i)
+	case "lfg":
+		handleAdminLFG(s, i, subcommand)
+	case "export-subscriptions":
+		handleAdminExport(s, i, subcommand)
+	case "import-subscriptions":
+		handleAdminImport(s, i, subcommand)
+	case "game":
+		handleAdminGame(s, i, subcommand.Options[0])
+	case "template":
+		handleAdminTemplate(s, i, subcommand.Options[0])
+	}
+}
+
+// outboxErrorLength is how much of each delivery error /admin outbox shows
+const outboxErrorLength = 120
+
+// handleAdminOutbox shows pending and dead-lettered notifications
+func handleAdminOutbox(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	stats := Outbox.Stats(i.GuildID)
+
+	var response strings.Builder
+	response.WriteString("📬 **Notification Outbox:**\n\n")
+	response.WriteString(fmt.Sprintf("Delivered since startup: **%d**\n", stats.Delivered))
+	response.WriteString(fmt.Sprintf("Pending: **%d**\n", len(stats.Pending)))
+	response.WriteString(fmt.Sprintf("Dead letters: **%d**\n", len(stats.DeadLetter)))
+
+	if len(stats.Pending) > 0 {
+		response.WriteString("\n**Retrying:**\n")
+		for _, entry := range lastEntries(stats.Pending, 5) {
+			response.WriteString(fmt.Sprintf("• %s (%s) - attempt %d, next <t:%d:R>",
+				entry.Subscription.Username, entry.Subscription.DeliveryMethod(), entry.Attempts+1, entry.NextAttempt.Unix()))
+			if entry.LastError != "" {
+				response.WriteString(fmt.Sprintf(" - `%s`", truncate(entry.LastError, outboxErrorLength)))
+			}
+			response.WriteString("\n")
+		}
+	}
+
+	if len(stats.DeadLetter) > 0 {
+		response.WriteString("\n**Recently failed:**\n")
+		for _, entry := range lastEntries(stats.DeadLetter, 5) {
+			response.WriteString(fmt.Sprintf("• %s (%s) - %s, gave up after %d attempts - `%s`\n",
+				entry.Subscription.Username, entry.Subscription.DeliveryMethod(),
+				entry.CreatedAt.Format(time.DateTime), entry.Attempts, truncate(entry.LastError, outboxErrorLength)))
+		}
+	}
+
+	// Discord rejects messages over 2000 characters
+	respondEphemeral(s, i, truncate(response.String(), 2000))
+}
+
+// handleAdminStats shows whether subscriptions are being saved and LFG notification counts since startup
+func handleAdminStats(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var response strings.Builder
+	if err := SubManager.LastSaveError(); err != nil {
+		response.WriteString(fmt.Sprintf("⚠️ Subscriptions aren't being saved: %s\n\n", truncate(err.Error(), 200)))
+	} else {
+		response.WriteString("💾 Subscriptions are being saved.\n\n")
+	}
+
+	if LFGStats == nil {
+		response.WriteString("📊 LFG isn't running yet.")
+		respondEphemeral(s, i, response.String())
+		return
+	}
+	stats := LFGStats.Snapshot(i.GuildID)
+
+	response.WriteString("📊 **LFG Notification Stats** (since startup):\n\n")
+	response.WriteString(fmt.Sprintf("Queued: **%d**\n", stats.Queued))
+	if len(stats.Suppressed) == 0 {
+		response.WriteString("Suppressed: **0**\n")
+	}
+	for _, reason := range stats.Reasons() {
+		response.WriteString(fmt.Sprintf("Suppressed by %s: **%d**\n", reason, stats.Suppressed[reason]))
+	}
+
+	respondEphemeral(s, i, response.String())
+}
+
+// handleAdminLFG shows or changes the guild's LFG channels
+func handleAdminLFG(s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) {
+	if len(subcommand.Options) > 0 {
+		err := Guilds.Update(i.GuildID, func(settings *data.GuildSettings) error {
+			for _, option := range subcommand.Options {
+				switch option.Name {
+				case "voice-channel":
+					settings.LFGChannelID = option.ChannelValue(nil).ID
+				case "announcement-channel":
+					settings.AnnouncementChannelID = option.ChannelValue(nil).ID
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("❌ Error: %s", err.Error()))
+			return
+		}
+	}
+
+	settings := Guilds.Get(i.GuildID)
+
+	var response strings.Builder
+	response.WriteString("🎮 **LFG Channels:**\n\n")
+	if settings.LFGChannelID != "" {
+		response.WriteString(fmt.Sprintf("Voice channel: <#%s>\n", settings.LFGChannelID))
+	} else {
+		response.WriteString("Voice channel: not set, use `/admin lfg voice-channel` to pick one\n")
+	}
+	if settings.AnnouncementChannelID != "" {
+		response.WriteString(fmt.Sprintf("Announcements: <#%s>\n", settings.AnnouncementChannelID))
+	} else {
+		response.WriteString("Announcements: picked automatically\n")
+	}
+
+	respondEphemeral(s, i, response.String())
+}
+
+// handleAdminTemplate shows, sets or resets the guild's message templates
+func handleAdminTemplate(s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) {
+	options := make(map[string]string)
+	for _, option := range subcommand.Options {
+		options[option.Name] = option.StringValue()
+	}
+
+	var err error
+	switch subcommand.Name {
+	case "set":
+		// Slash command options can't contain new lines
+		text := strings.ReplaceAll(options["template"], `\n`, "\n")
+		err = Templates.Set(i.GuildID, options["name"], text)
+	case "reset":
+		err = Templates.Reset(i.GuildID, options["name"])
+	}
+
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("❌ Error: %s", err.Error()))
+		return
+	}
+
+	// Every template in full can be longer than a Discord message, so only the
+	// one that changed is echoed and the list is cut short
+	var response strings.Builder
+	switch subcommand.Name {
+	case "set", "reset":
+		response.WriteString("📝 **Message Template Updated:**\n\n")
+		writeTemplate(&response, i.GuildID, options["name"], templateEchoLength)
+		response.WriteString("Use `/admin template show` to see every template.\n")
+	default:
+		response.WriteString("📝 **Message Templates:**\n\n")
+		for _, name := range data.TemplateNames() {
+			writeTemplate(&response, i.GuildID, name, templatePreviewLength)
+		}
+	}
+	response.WriteString("Variables: `{{.User}}` `{{.Game}}` `{{.GameID}}` `{{.VoiceChannel}}` `{{.Participants}}` `{{.PartySize}}` `{{.Delivery}}`")
+
+	respondEphemeral(s, i, response.String())
+}
+
+// writeTemplate writes a guild's template in a code block, cut to limit characters
+func writeTemplate(response *strings.Builder, guildID, name string, limit int) {
+	text, custom := Templates.Get(guildID, name)
+	label := "default"
+	if custom {
+		label = "custom"
+	}
+	response.WriteString(fmt.Sprintf("**%s** (%s)\n```\n%s\n```\n", name, label, truncate(text, limit)))
+}
+
+// lastEntries returns up to n entries from the end of the list
+func lastEntries(entries []notify.OutboxEntry, n int) []notify.OutboxEntry {
+	if len(entries) > n {
+		return entries[len(entries)-n:]
+	}
+	return entries
+}
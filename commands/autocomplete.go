@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"sort"
+	"strings"
+
+	"discord-bot/data"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxChoices is the most autocomplete suggestions Discord shows
+const maxChoices = 25
+
+// maxChoiceLength is the longest name Discord accepts for a suggestion
+const maxChoiceLength = 100
+
+// suggestion is something an autocomplete option can suggest
+type suggestion struct {
+	Name  string   // Shown to the user
+	Value string   // Sent back as the option's value
+	Terms []string // What the user may be typing, e.g. the game's name, ID and aliases
+}
+
+// gameSuggestion suggests a game from the catalog
+func gameSuggestion(game data.Game) suggestion {
+	return suggestion{
+		Name:  game.Name,
+		Value: game.ID,
+		Terms: append([]string{game.Name, game.ID}, game.Aliases...),
+	}
+}
+
+// catalogSuggestions suggests every game in the catalog
+func catalogSuggestions() []suggestion {
+	games := Games.All()
+	suggestions := make([]suggestion, 0, len(games))
+	for _, game := range games {
+		suggestions = append(suggestions, gameSuggestion(game))
+	}
+	return suggestions
+}
+
+// autocompleteGames suggests games from the catalog for the focused option
+func autocompleteGames(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	respondAutocomplete(s, i, rankSuggestions(focusedValue(i), catalogSuggestions()))
+}
+
+// respondAutocomplete sends autocomplete suggestions
+func respondAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate, choices []*discordgo.ApplicationCommandOptionChoice) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+}
+
+// focusedOption returns the option the user is typing in, looking inside
+// subcommands and subcommand groups
+func focusedOption(options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, option := range options {
+		if option.Focused {
+			return option
+		}
+		if focused := focusedOption(option.Options); focused != nil {
+			return focused
+		}
+	}
+	return nil
+}
+
+// focusedValue returns what the user has typed so far in the focused option
+func focusedValue(i *discordgo.InteractionCreate) string {
+	if option := focusedOption(i.ApplicationCommandData().Options); option != nil {
+		return option.StringValue()
+	}
+	return ""
+}
+
+// rankSuggestions returns the suggestions that match a query as choices, best
+// match first. An empty query keeps the suggestions in order.
+func rankSuggestions(query string, suggestions []suggestion) []*discordgo.ApplicationCommandOptionChoice {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	type ranked struct {
+		suggestion
+		score int
+	}
+	var matches []ranked
+	for _, s := range suggestions {
+		best := -1
+		for _, term := range s.Terms {
+			best = max(best, fuzzyScore(query, strings.ToLower(term)))
+		}
+		if best >= 0 {
+			matches = append(matches, ranked{suggestion: s, score: best})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, min(len(matches), maxChoices))
+	for _, match := range matches {
+		if len(choices) == maxChoices {
+			break
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  truncate(match.Name, maxChoiceLength),
+			Value: match.Value,
+		})
+	}
+	return choices
+}
+
+// fuzzyScore scores how well text matches a query, or returns -1 if it
+// doesn't. Exact matches beat prefixes, then word prefixes, initials,
+// substrings and finally the query's letters appearing in order.
+func fuzzyScore(query, text string) int {
+	switch {
+	case query == "":
+		return 0
+	case text == query:
+		return 1000
+	case strings.HasPrefix(text, query):
+		return 800 - len(text)
+	}
+
+	words := strings.FieldsFunc(text, func(r rune) bool { return r == ' ' || r == '-' })
+	var initials strings.Builder
+	for _, word := range words {
+		if strings.HasPrefix(word, query) {
+			return 600 - len(text)
+		}
+		initials.WriteByte(word[0])
+	}
+
+	// Initials, e.g. "rl" for "rocket league"
+	if strings.HasPrefix(initials.String(), query) {
+		return 500
+	}
+
+	if index := strings.Index(text, query); index >= 0 {
+		return 400 - index
+	}
+
+	// Every letter of the query in order, e.g. "mcraft" in "minecraft"
+	gaps, next := 0, 0
+	for _, r := range query {
+		found := strings.IndexRune(text[next:], r)
+		if found < 0 {
+			return -1
+		}
+		gaps += found
+		next += found + len(string(r))
+	}
+	return max(200-gaps, 1)
+}
@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"discord-bot/data"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// RegisterAvailability registers the availability slash command
+func RegisterAvailability() {
+	gameOption := func() *discordgo.ApplicationCommandOption {
+		return &discordgo.ApplicationCommandOption{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         "game",
+			Description:  "One of your subscribed games",
+			Required:     true,
+			Autocomplete: true,
+		}
+	}
+
+	Register(&SlashCommand{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "availability",
+			Description: "Choose when you want to hear about each game",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "show",
+					Description: "Show when each of your subscriptions notifies you",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Add a time you want to hear about a game",
+					Options: []*discordgo.ApplicationCommandOption{
+						gameOption(),
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "start",
+							Description: "When the window starts (e.g. 19:00)",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "end",
+							Description: "When the window ends (e.g. 23:00)",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "days",
+							Description: "Which days, like mon-fri, sat,sun, weekdays or weekends (default: every day)",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "clear",
+					Description: "Hear about a game any time again",
+					Options: []*discordgo.ApplicationCommandOption{
+						gameOption(),
+					},
+				},
+			},
+		},
+		Handler:      handleAvailability,
+		Autocomplete: autocompleteSubscriptions,
+	})
+}
+
+// handleAvailability handles the availability command
+func handleAvailability(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := i.Member.User
+	subcommand := i.ApplicationCommandData().Options[0]
+
+	options := make(map[string]string)
+	for _, option := range subcommand.Options {
+		options[option.Name] = option.StringValue()
+	}
+
+	game := data.NormalizeGameID(options["game"])
+	if entry, exists := Games.Resolve(game); exists {
+		game = entry.ID
+	}
+
+	var err error
+	switch subcommand.Name {
+	case "add":
+		err = addAvailability(i.GuildID, user.ID, game, options)
+	case "clear":
+		err = SubManager.SetAvailability(i.GuildID, user.ID, game, nil)
+	}
+
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("❌ Error: %s", err.Error()))
+		return
+	}
+
+	respondEphemeral(s, i, describeAvailability(Profiles.Get(user.ID), SubManager.GetSubscriptions(i.GuildID, user.ID)))
+}
+
+// addAvailability adds a window to one of the user's subscriptions
+func addAvailability(guildID, userID, game string, options map[string]string) error {
+	window, err := data.ParseTimeWindow(options["start"], options["end"])
+	if err != nil {
+		return err
+	}
+	days, err := data.ParseWeekdays(options["days"])
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range SubManager.GetSubscriptions(guildID, userID) {
+		if sub.Game == game {
+			windows := append(slices.Clone(sub.Availability), data.AvailabilityWindow{Days: days, TimeWindow: window})
+			return SubManager.SetAvailability(guildID, userID, game, windows)
+		}
+	}
+	return fmt.Errorf("not subscribed to %s", game)
+}
+
+// describeAvailability formats when each of a user's subscriptions notifies them
+func describeAvailability(profile data.UserProfile, subscriptions []data.GameSubscription) string {
+	if len(subscriptions) == 0 {
+		return "📱 You're not subscribed to any games yet!\nUse `/subscribe` to get started."
+	}
+
+	var response strings.Builder
+	response.WriteString("🕒 **Your Availability:**\n\n")
+	for _, sub := range subscriptions {
+		response.WriteString(fmt.Sprintf("🎮 **%s** → %s\n", Games.DisplayName(sub.Game), describeWindows(sub.Availability)))
+	}
+
+	timeZone := profile.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC (use `/quiet-hours timezone` to change)"
+	}
+	response.WriteString(fmt.Sprintf("\nTime zone: %s", timeZone))
+	return response.String()
+}
+
+// describeWindows formats availability windows, or "any time" if there are none
+func describeWindows(windows []data.AvailabilityWindow) string {
+	if len(windows) == 0 {
+		return "any time"
+	}
+	formatted := make([]string, 0, len(windows))
+	for _, window := range windows {
+		formatted = append(formatted, fmt.Sprintf("`%s`", window))
+	}
+	return strings.Join(formatted, ", ")
+}
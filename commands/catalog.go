@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"discord-bot/data"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// gameOptions returns the options for adding or editing a game. The name is
+// only required when adding.
+func gameOptions(adding bool) []*discordgo.ApplicationCommandOption {
+	minPartySize := float64(0)
+
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         "id",
+			Description:  "The game's ID, e.g. rocket-league",
+			Required:     true,
+			Autocomplete: !adding,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "name",
+			Description: "Display name, e.g. Rocket League",
+			Required:    adding,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "aliases",
+			Description: "Other names people may type, separated by commas (e.g. rl, rocketleague)",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "emoji",
+			Description: "NTFY emoji short code for notifications, e.g. soccer",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "party-size",
+			Description: "How many people make a full party (0 if it doesn't matter)",
+			Required:    false,
+			MinValue:    &minPartySize,
+		},
+	}
+}
+
+// handleAdminGame lists, adds, edits or removes games in the catalog
+func handleAdminGame(s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) {
+	options := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, option := range subcommand.Options {
+		options[option.Name] = option
+	}
+
+	// applyOptions copies the given options onto a game
+	applyOptions := func(game *data.Game) error {
+		if option, exists := options["name"]; exists {
+			game.Name = option.StringValue()
+		}
+		if option, exists := options["aliases"]; exists {
+			game.Aliases = strings.Split(option.StringValue(), ",")
+		}
+		if option, exists := options["emoji"]; exists {
+			game.Emoji = option.StringValue()
+		}
+		if option, exists := options["party-size"]; exists {
+			game.PartySize = int(option.IntValue())
+		}
+		return nil
+	}
+
+	var response string
+	var err error
+	switch subcommand.Name {
+	case "add":
+		game := data.Game{ID: options["id"].StringValue()}
+		applyOptions(&game)
+		if err = Games.Add(game); err == nil {
+			game, _ = Games.Resolve(game.ID)
+			response = fmt.Sprintf("✅ Added %s.%s", describeGame(game), adoptAliases(game))
+		}
+	case "edit":
+		var game data.Game
+		game, err = Games.Edit(data.NormalizeGameID(options["id"].StringValue()), applyOptions)
+		if err == nil {
+			response = fmt.Sprintf("✅ Updated %s.%s", describeGame(game), adoptAliases(game))
+		}
+	case "remove":
+		id := data.NormalizeGameID(options["id"].StringValue())
+		if err = Games.Remove(id); err == nil {
+			response = fmt.Sprintf("✅ Removed **%s**. People subscribed to it keep their subscriptions until they unsubscribe.", id)
+		}
+	default:
+		response = describeCatalog()
+	}
+
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("❌ Error: %s", err.Error()))
+		return
+	}
+	respondEphemeral(s, i, response)
+}
+
+// adoptAliases moves subscriptions made under a game's aliases over to the
+// game, and describes how many were moved
+func adoptAliases(game data.Game) string {
+	moved := 0
+	for _, alias := range game.Aliases {
+		moved += SubManager.RenameGame(alias, game.ID)
+	}
+	if moved == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nMoved %d subscription(s) from its aliases.", moved)
+}
+
+// describeCatalog lists every game in the catalog
+func describeCatalog() string {
+	games := Games.All()
+	if len(games) == 0 {
+		return "🎮 The game list is empty, use `/admin game add` to add one."
+	}
+
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("🎮 **Game List** (%d games)\n\n", len(games)))
+	for n, game := range games {
+		// Stay well inside Discord's message length limit
+		if response.Len() > 1800 {
+			response.WriteString(fmt.Sprintf("…and %d more\n", len(games)-n))
+			break
+		}
+		response.WriteString(fmt.Sprintf("• %s\n", describeGame(game)))
+	}
+	return response.String()
+}
+
+// describeGame formats a single game from the catalog
+func describeGame(game data.Game) string {
+	var line strings.Builder
+	line.WriteString(fmt.Sprintf("**%s** `%s`", game.Name, game.ID))
+	if len(game.Aliases) > 0 {
+		line.WriteString(fmt.Sprintf(" (also `%s`)", strings.Join(game.Aliases, "`, `")))
+	}
+	if game.Emoji != "" {
+		line.WriteString(fmt.Sprintf(" :%s:", game.Emoji))
+	}
+	if game.PartySize > 0 {
+		line.WriteString(fmt.Sprintf(" party of %d", game.PartySize))
+	}
+	return line.String()
+}
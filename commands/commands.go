@@ -1,11 +1,16 @@
 package commands
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
 
 // SlashCommand represents a slash command
 type SlashCommand struct {
-	Definition *discordgo.ApplicationCommand
-	Handler    func(s *discordgo.Session, i *discordgo.InteractionCreate)
+	Definition   *discordgo.ApplicationCommand
+	Handler      func(s *discordgo.Session, i *discordgo.InteractionCreate)
+	Autocomplete func(s *discordgo.Session, i *discordgo.InteractionCreate) // Suggests values for options with Autocomplete set
 }
 
 // Registry holds all registered slash commands
@@ -16,6 +21,25 @@ func Register(cmd *SlashCommand) {
 	Registry[cmd.Definition.Name] = cmd
 }
 
+// ComponentHandler handles presses of buttons and other message components
+type ComponentHandler func(s *discordgo.Session, i *discordgo.InteractionCreate, args []string)
+
+// Components holds message component handlers by custom ID prefix
+var Components = make(map[string]ComponentHandler)
+
+// RegisterComponent handles message components whose custom ID is the prefix,
+// optionally followed by ":"-separated arguments
+func RegisterComponent(prefix string, handler ComponentHandler) {
+	Components[prefix] = handler
+}
+
+// GetComponent returns the handler for a component's custom ID and its arguments
+func GetComponent(customID string) (ComponentHandler, []string, bool) {
+	parts := strings.Split(customID, ":")
+	handler, exists := Components[parts[0]]
+	return handler, parts[1:], exists
+}
+
 // GetAll returns all registered commands
 func GetAll() map[string]*SlashCommand {
 	return Registry
@@ -43,6 +67,26 @@ func Initialize() {
 	RegisterRoll()
 	RegisterSubscribe()
 	RegisterUnsubscribe()
+	RegisterVerify()
 	RegisterMyGames()
+	RegisterProfile()
 	RegisterGamesList()
+	RegisterQuietHours()
+	RegisterAvailability()
+	RegisterTestNotify()
+	RegisterNotifications()
+	RegisterMyData()
+	RegisterForgetMe()
+	RegisterAdmin()
+}
+
+// respondEphemeral replies to an interaction with a message only the user can see
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
 }
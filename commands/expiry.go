@@ -0,0 +1,222 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-bot/data"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxSubscriptionDuration is the longest a time-limited subscription can last
+	maxSubscriptionDuration = 365 * 24 * time.Hour
+
+	// renewTTL is how long the renew button on a lapsed subscription works
+	renewTTL = 7 * 24 * time.Hour
+
+	// maxCustomIDLength is the longest custom ID Discord accepts on a component
+	maxCustomIDLength = 100
+)
+
+// durationUnits are the units a subscription duration can be given in
+var durationUnits = map[byte]time.Duration{
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+}
+
+// parseSubscriptionDuration parses a duration like "3d", "12h" or "1w2d"
+func parseSubscriptionDuration(input string) (time.Duration, error) {
+	input = strings.ToLower(strings.ReplaceAll(input, " ", ""))
+	if input == "" {
+		return 0, fmt.Errorf("duration can't be empty")
+	}
+
+	var total time.Duration
+	for rest := input; rest != ""; {
+		digits := len(rest) - len(strings.TrimLeft(rest, "0123456789"))
+		if digits == 0 || digits == len(rest) {
+			return 0, fmt.Errorf("invalid duration %q, use something like `3d`, `12h` or `1w`", input)
+		}
+		unit, exists := durationUnits[rest[digits]]
+		if !exists {
+			return 0, fmt.Errorf("invalid duration %q, use m, h, d or w for minutes, hours, days or weeks", input)
+		}
+		n, err := strconv.Atoi(rest[:digits])
+		if err != nil || n > int(maxSubscriptionDuration/unit) {
+			return 0, fmt.Errorf("duration can't be longer than %d days", int(maxSubscriptionDuration.Hours()/24))
+		}
+		total += time.Duration(n) * unit
+		rest = rest[digits+1:]
+	}
+
+	if total <= 0 {
+		return 0, fmt.Errorf("duration must be longer than zero")
+	}
+	if total > maxSubscriptionDuration {
+		return 0, fmt.Errorf("duration can't be longer than %d days", int(maxSubscriptionDuration.Hours()/24))
+	}
+	return total, nil
+}
+
+// limitSubscription makes a subscription expire after a duration like "3d"
+func limitSubscription(sub *data.GameSubscription, duration string, now time.Time) error {
+	length, err := parseSubscriptionDuration(duration)
+	if err != nil {
+		return err
+	}
+	sub.Duration = strings.ToLower(strings.ReplaceAll(duration, " ", ""))
+	// Whole seconds in UTC so the time is the same after a save and load
+	sub.ExpiresAt = now.Add(length).UTC().Truncate(time.Second)
+	return nil
+}
+
+// describeExpiry formats when a time-limited subscription runs out, or
+// returns "" for one that doesn't
+func describeExpiry(sub data.GameSubscription) string {
+	if sub.ExpiresAt.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("⌛ until <t:%d:f>", sub.ExpiresAt.Unix())
+}
+
+// SendLapsedNotice DMs a user that their time-limited subscription expired,
+// with a button to renew it for the same length of time
+func SendLapsedNotice(s *discordgo.Session, sub data.GameSubscription) error {
+	channel, err := s.UserChannelCreate(sub.UserID)
+	if err != nil {
+		return fmt.Errorf("error opening DM channel: %v", err)
+	}
+
+	content := fmt.Sprintf("⌛ Your %s subscription for **%s** has ended, so you won't be notified about it anymore.",
+		sub.Duration, Games.DisplayName(sub.Game))
+	message := &discordgo.MessageSend{Content: content}
+
+	if customID, fits := renewCustomID(sub, time.Now().Add(renewTTL)); fits {
+		// Only what fits in the button comes back when it's renewed
+		if !sub.UsesProfile() {
+			message.Content += " Renewing it uses your `/profile` delivery settings."
+		}
+		if len(sub.Availability) > 0 {
+			message.Content += " Set its `/availability` again after renewing."
+		}
+		message.Components = []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    fmt.Sprintf("Renew for %s", sub.Duration),
+						Style:    discordgo.PrimaryButton,
+						CustomID: customID,
+					},
+				},
+			},
+		}
+	} else {
+		message.Content += " Use `/subscribe` to renew it."
+	}
+
+	if _, err := s.ChannelMessageSendComplex(channel.ID, message); err != nil {
+		return fmt.Errorf("error sending DM: %v", err)
+	}
+	return nil
+}
+
+// renewCustomID encodes a lapsed subscription in the renew button's custom
+// ID, so the button keeps working after a restart. Delivery settings and
+// availability aren't included, they can be too long and delivery targets
+// shouldn't be sent around. The game goes last because its ID can contain
+// colons. It reports false if the ID would be too long for Discord.
+func renewCustomID(sub data.GameSubscription, deadline time.Time) (string, bool) {
+	customID := fmt.Sprintf("renew:%s:%s:%d:%d:%d:%s",
+		sub.GuildID, sub.Duration, deadline.Unix(), sub.Priority, sub.MinPlayers, sub.Game)
+	return customID, len(customID) <= maxCustomIDLength
+}
+
+// parseRenewArgs reads a lapsed subscription back from a renew button's
+// custom ID and returns it with the deadline for renewing it
+func parseRenewArgs(args []string, user *discordgo.User) (data.GameSubscription, time.Time, error) {
+	if len(args) < 6 {
+		return data.GameSubscription{}, time.Time{}, fmt.Errorf("malformed renew button")
+	}
+	deadline, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return data.GameSubscription{}, time.Time{}, fmt.Errorf("malformed renew button")
+	}
+	priority, err := strconv.Atoi(args[3])
+	if err != nil {
+		return data.GameSubscription{}, time.Time{}, fmt.Errorf("malformed renew button")
+	}
+	minPlayers, err := strconv.Atoi(args[4])
+	if err != nil {
+		return data.GameSubscription{}, time.Time{}, fmt.Errorf("malformed renew button")
+	}
+
+	sub := data.GameSubscription{
+		GuildID:    args[0],
+		UserID:     user.ID,
+		Username:   user.Username,
+		Game:       strings.Join(args[5:], ":"),
+		Duration:   args[1],
+		Priority:   priority,
+		MinPlayers: minPlayers,
+	}
+	return sub, time.Unix(deadline, 0), nil
+}
+
+// handleRenewButton subscribes the user again for the same length of time
+func handleRenewButton(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	// The notice is a DM, where there's no member
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+
+	var content string
+	sub, deadline, err := parseRenewArgs(args, user)
+	switch {
+	case err != nil || time.Now().After(deadline):
+		// Buttons sent before renewals were encoded in the button end up here too
+		content = "⌛ This renewal expired, run `/subscribe` again."
+	case !isGuildMember(s, sub.GuildID, user.ID):
+		content = "❌ Error: you're no longer in that server"
+	default:
+		if _, exists := Games.Resolve(sub.Game); !exists {
+			err = fmt.Errorf("%s isn't in the game list anymore", sub.Game)
+		}
+		if err == nil {
+			err = limitSubscription(&sub, sub.Duration, time.Now())
+		}
+		if err == nil {
+			err = SubManager.Subscribe(sub)
+		}
+		if err != nil {
+			content = fmt.Sprintf("❌ Error: %s", err.Error())
+		} else {
+			content = fmt.Sprintf("✅ Renewed your **%s** subscription %s.",
+				Games.DisplayName(sub.Game), describeExpiry(sub))
+			fmt.Printf("🔁 %s renewed %s for %s\n", sub.Username, sub.Game, sub.Duration)
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
+
+// isGuildMember reports whether a user is still in a guild
+func isGuildMember(s *discordgo.Session, guildID, userID string) bool {
+	if _, err := s.State.Member(guildID, userID); err == nil {
+		return true
+	}
+	_, err := s.GuildMember(guildID, userID)
+	return err == nil
+}
@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"discord-bot/data"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxSelectOptions is the most options a Discord select menu can have
+	maxSelectOptions = 25
+
+	// maxActionRows is the most rows of components a Discord message can have
+	maxActionRows = 5
+)
+
+// handleGamePicker replies with select menus of the catalog, checked with the
+// games the user is already subscribed to
+func handleGamePicker(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	content := "🎮 **Pick your games**\n\n" +
+		"Check the games you want notifications for and uncheck the ones you don't. " +
+		"New subscriptions use your `/profile` delivery settings."
+
+	components := gamePickerComponents(i.GuildID, i.Member.User.ID)
+	if len(components) == 0 {
+		respondEphemeral(s, i, "❌ Error: the game list is empty, ask an admin to add games with `/admin game add`")
+		return
+	}
+	if hidden := len(Games.All()) - maxSelectOptions*maxActionRows; hidden > 0 {
+		content += fmt.Sprintf("\n\n%d more game(s) didn't fit, use `/subscribe game:` for those.", hidden)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Flags:      discordgo.MessageFlagsEphemeral,
+			Components: components,
+		},
+	})
+}
+
+// handleGamePickerSelect subscribes and unsubscribes the user so the games
+// offered by one menu match what they checked in it
+func handleGamePickerSelect(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	user := i.Member.User
+	selectData := i.MessageComponentData()
+
+	checked := make(map[string]bool)
+	for _, value := range selectData.Values {
+		checked[value] = true
+	}
+	current := make(map[string]data.GameSubscription)
+	for _, sub := range SubManager.GetSubscriptions(i.GuildID, user.ID) {
+		current[sub.Game] = sub
+	}
+
+	// Only the games this menu offered change, the user's other subscriptions are kept
+	var changes data.SubscriptionChanges
+	for _, game := range offeredGames(i.Message, selectData.CustomID) {
+		sub, subscribed := current[game]
+		switch {
+		case checked[game] && !subscribed:
+			if _, exists := Games.Resolve(game); !exists {
+				continue
+			}
+			changes.Added = append(changes.Added, data.GameSubscription{
+				GuildID:  i.GuildID,
+				UserID:   user.ID,
+				Username: user.Username,
+				Game:     game,
+			})
+		case !checked[game] && subscribed:
+			changes.Removed = append(changes.Removed, sub)
+		}
+	}
+
+	var content string
+	if err := SubManager.Apply(changes); err != nil {
+		content = fmt.Sprintf("❌ Error: nothing was changed: %s", err.Error())
+	} else {
+		content = describePickerChanges(changes)
+		if !changes.Empty() {
+			fmt.Printf("🎮 %s picked games: %d added, %d removed\n", user.Username, len(changes.Added), len(changes.Removed))
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: gamePickerComponents(i.GuildID, user.ID),
+		},
+	})
+}
+
+// gamePickerComponents builds one select menu per 25 games in the catalog
+func gamePickerComponents(guildID, userID string) []discordgo.MessageComponent {
+	subscribed := make(map[string]bool)
+	for _, sub := range SubManager.GetSubscriptions(guildID, userID) {
+		subscribed[sub.Game] = true
+	}
+
+	games := Games.All()
+	minValues := 0
+	var rows []discordgo.MessageComponent
+	for start := 0; start < len(games) && len(rows) < maxActionRows; start += maxSelectOptions {
+		page := games[start:min(start+maxSelectOptions, len(games))]
+
+		options := make([]discordgo.SelectMenuOption, 0, len(page))
+		for _, game := range page {
+			options = append(options, discordgo.SelectMenuOption{
+				Label:   truncate(game.Name, 100),
+				Value:   game.ID,
+				Default: subscribed[game.ID],
+			})
+		}
+
+		rows = append(rows, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					MenuType:    discordgo.StringSelectMenu,
+					CustomID:    fmt.Sprintf("pick-games:%d", len(rows)),
+					Placeholder: truncate(fmt.Sprintf("%s – %s", page[0].Name, page[len(page)-1].Name), 150),
+					MinValues:   &minValues,
+					MaxValues:   len(options),
+					Options:     options,
+				},
+			},
+		})
+	}
+	return rows
+}
+
+// offeredGames returns the games a select menu on a message offered, so a
+// change to the catalog since the menu was sent can't touch other games
+func offeredGames(message *discordgo.Message, customID string) []string {
+	if message == nil {
+		return nil
+	}
+	for _, component := range message.Components {
+		row, ok := component.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, child := range row.Components {
+			menu, ok := child.(*discordgo.SelectMenu)
+			if !ok || menu.CustomID != customID {
+				continue
+			}
+			games := make([]string, 0, len(menu.Options))
+			for _, option := range menu.Options {
+				games = append(games, option.Value)
+			}
+			return games
+		}
+	}
+	return nil
+}
+
+// describePickerChanges formats what a game picker selection changed
+func describePickerChanges(changes data.SubscriptionChanges) string {
+	if changes.Empty() {
+		return "🎮 **Pick your games**\n\nNo changes, you're subscribed to the games that are checked."
+	}
+
+	names := func(subs []data.GameSubscription) string {
+		list := make([]string, 0, len(subs))
+		for _, sub := range subs {
+			list = append(list, Games.DisplayName(sub.Game))
+		}
+		return strings.Join(list, ", ")
+	}
+
+	var response strings.Builder
+	response.WriteString("🎮 **Pick your games**\n\n")
+	if len(changes.Added) > 0 {
+		response.WriteString(fmt.Sprintf("✅ Subscribed to %s\n", names(changes.Added)))
+	}
+	if len(changes.Removed) > 0 {
+		response.WriteString(fmt.Sprintf("➖ Unsubscribed from %s\n", names(changes.Removed)))
+	}
+	response.WriteString("\nNew subscriptions use your `/profile` delivery settings.")
+	return response.String()
+}
+
+// truncate shortens text to at most limit characters, for Discord's length limits
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"discord-bot/data"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Global notification history - initialized in main
+var History *data.HistoryManager
+
+// historyPageSize is how many notifications are shown per page
+const historyPageSize = 10
+
+// RegisterNotifications registers the notifications slash command
+func RegisterNotifications() {
+	minPage := float64(1)
+
+	Register(&SlashCommand{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "notifications",
+			Description: "See which notifications were sent to you and why",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "page",
+					Description: "Page to show (default: 1, the most recent)",
+					Required:    false,
+					MinValue:    &minPage,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "scope",
+					Description: "Whose notifications to show (server-wide needs admin)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Mine", Value: "me"},
+						{Name: "Whole server", Value: "guild"},
+					},
+				},
+			},
+		},
+		Handler: handleNotifications,
+	})
+}
+
+// handleNotifications handles the notifications command
+func handleNotifications(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	page := 1
+	scope := "me"
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "page":
+			page = int(option.IntValue())
+		case "scope":
+			scope = option.StringValue()
+		}
+	}
+
+	var entries []data.HistoryEntry
+	var title string
+	if scope == "guild" {
+		if i.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+			respondEphemeral(s, i, "❌ Error: only admins can see the whole server's notifications")
+			return
+		}
+		entries = History.ForGuild(i.GuildID)
+		title = "📜 **Server Notification History**"
+	} else {
+		entries = History.ForUser(i.Member.User.ID)
+		title = "📜 **Your Notification History**"
+	}
+
+	if len(entries) == 0 {
+		respondEphemeral(s, i, "📜 No notifications have been sent yet.")
+		return
+	}
+
+	pages := (len(entries) + historyPageSize - 1) / historyPageSize
+	if page > pages {
+		page = pages
+	}
+	start := (page - 1) * historyPageSize
+	end := min(start+historyPageSize, len(entries))
+
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("%s (page %d/%d)\n\n", title, page, pages))
+	for _, entry := range entries[start:end] {
+		response.WriteString(formatHistoryEntry(entry, scope == "guild"))
+	}
+
+	respondEphemeral(s, i, response.String())
+}
+
+// formatHistoryEntry formats a single history line
+func formatHistoryEntry(entry data.HistoryEntry, showRecipient bool) string {
+	var line strings.Builder
+	line.WriteString(fmt.Sprintf("<t:%d:f> ", entry.Time.Unix()))
+
+	if entry.Game != "" {
+		line.WriteString(fmt.Sprintf("**%s**", Games.DisplayName(entry.Game)))
+	}
+	if entry.InitiatorName != "" {
+		line.WriteString(fmt.Sprintf(" from %s", entry.InitiatorName))
+	}
+	if showRecipient {
+		line.WriteString(fmt.Sprintf(" → %s", entry.Username))
+	}
+	line.WriteString(fmt.Sprintf(" via %s: %s %s", entry.Backend, outcomeEmoji(entry.Outcome), entry.Outcome))
+	if entry.Reason != "" {
+		line.WriteString(fmt.Sprintf(" (%s)", entry.Reason))
+	}
+	line.WriteString("\n")
+	return line.String()
+}
+
+// outcomeEmoji returns the emoji for a notification outcome
+func outcomeEmoji(outcome string) string {
+	switch outcome {
+	case data.OutcomeSent:
+		return "✅"
+	case data.OutcomeRetrying:
+		return "🔁"
+	case data.OutcomeFailed:
+		return "❌"
+	case data.OutcomeHeld:
+		return "🌙"
+	default:
+		return "🔕"
+	}
+}
@@ -0,0 +1,307 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"discord-bot/data"
+	"discord-bot/lfg"
+	"discord-bot/notify"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Global audit log - initialized in main
+var Audit *data.AuditLog
+
+// LFG throttle and notification callbacks - set when the bot starts, nil until then
+var (
+	Throttle  *lfg.Throttle
+	Callbacks *notify.CallbackServer
+)
+
+// personalData is everything the bot holds about a user, as sent by /mydata
+type personalData struct {
+	ExportedAt    time.Time               `json:"exported_at"`
+	UserID        string                  `json:"user_id"`
+	Subscriptions []data.GameSubscription `json:"subscriptions"`
+	Profile       data.UserProfile        `json:"profile"`
+	Notifications []data.HistoryEntry     `json:"notifications"`
+	Queued        []notify.OutboxEntry    `json:"queued_notifications"`
+	Stats         personalStats           `json:"stats"`
+}
+
+// personalStats summarizes a user's notification history
+type personalStats struct {
+	NotificationsByOutcome map[string]int `json:"notifications_by_outcome"`
+	NotificationsByGame    map[string]int `json:"notifications_by_game"`
+	NotificationsTriggered int            `json:"notifications_triggered"` // Sent to others about sessions they started
+}
+
+// RegisterMyData registers the mydata slash command
+func RegisterMyData() {
+	Register(&SlashCommand{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "mydata",
+			Description: "Get a copy of everything the bot knows about you by DM",
+		},
+		Handler: handleMyData,
+	})
+}
+
+// RegisterForgetMe registers the forgetme slash command
+func RegisterForgetMe() {
+	Register(&SlashCommand{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "forgetme",
+			Description: "Delete everything the bot knows about you",
+		},
+		Handler: handleForgetMe,
+	})
+	RegisterComponent("forgetme", handleForgetMeButton)
+}
+
+// handleMyData DMs the user a JSON file of their data
+func handleMyData(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := i.Member.User
+
+	// Gathering and sending the file can take a moment, so acknowledge first
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	content := "📬 Sent you a DM with everything the bot knows about you."
+	if err := sendPersonalData(s, user.ID); err != nil {
+		content = fmt.Sprintf("❌ Error: couldn't DM you your data, check that you allow DMs from this server: %s", err.Error())
+	} else {
+		fmt.Printf("📦 Sent %s their data\n", user.Username)
+	}
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+}
+
+// sendPersonalData DMs a user their data as a JSON file
+func sendPersonalData(s *discordgo.Session, userID string) error {
+	contents, err := json.MarshalIndent(collectPersonalData(userID), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	channel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		return fmt.Errorf("error opening DM channel: %v", err)
+	}
+	_, err = s.ChannelMessageSendComplex(channel.ID, &discordgo.MessageSend{
+		Content: "📦 Here's everything the bot knows about you. Use `/forgetme` to delete it.",
+		Files: []*discordgo.File{
+			{
+				Name:        fmt.Sprintf("mydata-%s.json", userID),
+				ContentType: "application/json",
+				Reader:      bytes.NewReader(contents),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error sending DM: %v", err)
+	}
+	return nil
+}
+
+// collectPersonalData gathers a user's data from every store
+func collectPersonalData(userID string) personalData {
+	export := personalData{
+		ExportedAt:    time.Now().UTC(),
+		UserID:        userID,
+		Subscriptions: SubManager.GetUserSubscriptions(userID),
+		Profile:       Profiles.Get(userID),
+		Stats: personalStats{
+			NotificationsByOutcome: make(map[string]int),
+			NotificationsByGame:    make(map[string]int),
+		},
+	}
+
+	if History != nil {
+		export.Notifications = History.ForUser(userID)
+		// Only counted, the entries themselves are about other people
+		export.Stats.NotificationsTriggered = len(History.Initiated(userID))
+	}
+	for _, entry := range export.Notifications {
+		export.Stats.NotificationsByOutcome[entry.Outcome]++
+		if entry.Outcome == data.OutcomeSent {
+			export.Stats.NotificationsByGame[entry.Game]++
+		}
+	}
+	if Outbox != nil {
+		export.Queued = Outbox.ForUser(userID)
+	}
+	return export
+}
+
+// handleForgetMe asks the user to confirm they want their data deleted
+func handleForgetMe(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := i.Member.User
+	content := "🗑️ **Forget me**\n\n" +
+		"This deletes your subscriptions in every server, your profile, quiet hours and availability, " +
+		"your notification history and any notifications waiting to be sent. It can't be undone.\n\n" +
+		"Use `/mydata` first if you want a copy."
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{Label: "Delete my data", Style: discordgo.DangerButton, CustomID: "forgetme:confirm:" + user.ID},
+						discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "forgetme:cancel:" + user.ID},
+					},
+				},
+			},
+		},
+	})
+}
+
+// handleForgetMeButton deletes the user's data or cancels
+func handleForgetMeButton(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if len(args) != 2 {
+		return
+	}
+	action, userID := args[0], args[1]
+	user := i.Member.User
+	if user.ID != userID {
+		respondEphemeral(s, i, "❌ Error: only the person who ran `/forgetme` can confirm it")
+		return
+	}
+
+	var content string
+	if action == "cancel" {
+		content = "🚫 Nothing was deleted."
+	} else {
+		removed, err := forgetUser(userID)
+		entry := data.AuditEntry{
+			Action:  data.AuditErasure,
+			Subject: Audit.HashUserID(userID),
+			Removed: removed,
+		}
+		if err != nil {
+			entry.Error = err.Error()
+			content = fmt.Sprintf("❌ Error: some of your data couldn't be deleted, please try again: %s", err.Error())
+		} else {
+			content = "🗑️ Done, the bot has forgotten you. Use `/subscribe` if you ever want notifications again."
+		}
+		if err := Audit.Record(entry); err != nil {
+			log.Printf("Error recording erasure in audit log: %v", err)
+		}
+		fmt.Printf("🗑️ Forgot a user's data (%s)\n", describeRemoved(removed))
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
+
+// forgetUser removes a user from every store and returns how many records
+// each store removed or changed. It keeps going when a store fails, so as
+// much as possible is deleted, and returns the failures together.
+func forgetUser(userID string) (map[string]int, error) {
+	removed := make(map[string]int)
+	var failures []string
+
+	subscriptions := SubManager.GetUserSubscriptions(userID)
+	if err := SubManager.Apply(data.SubscriptionChanges{Removed: subscriptions}); err != nil {
+		failures = append(failures, fmt.Sprintf("subscriptions: %v", err))
+	} else {
+		removed["subscriptions"] = len(subscriptions)
+	}
+
+	if deleted, err := Profiles.Delete(userID); err != nil {
+		failures = append(failures, fmt.Sprintf("profile: %v", err))
+	} else if deleted {
+		removed["profile"] = 1
+	}
+
+	if History != nil {
+		n, err := History.ForgetUser(userID)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("history: %v", err))
+		}
+		removed["history"] = n
+	}
+
+	if Outbox != nil {
+		n, err := Outbox.ForgetUser(userID)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("outbox: %v", err))
+		}
+		removed["outbox"] = n
+	}
+
+	// Nothing below is saved to disk, it only lives until the bot restarts
+	if Throttle != nil {
+		Throttle.ForgetUser(userID)
+	}
+	if Callbacks != nil {
+		Callbacks.ForgetUser(userID)
+	}
+	removed["imports"] = forgetPendingImports(userID)
+	forgetVerifications(userID)
+
+	if len(failures) > 0 {
+		return removed, fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	return removed, nil
+}
+
+// forgetPendingImports drops imports the user started and takes the user
+// out of imports other admins started
+func forgetPendingImports(userID string) int {
+	pendingImportMutex.Lock()
+	defer pendingImportMutex.Unlock()
+
+	forgotten := 0
+	for id, pending := range pendingImports {
+		if pending.adminID == userID {
+			delete(pendingImports, id)
+			forgotten++
+			continue
+		}
+		kept := pending.subscriptions[:0]
+		for _, sub := range pending.subscriptions {
+			if sub.UserID != userID {
+				kept = append(kept, sub)
+			}
+		}
+		if len(kept) != len(pending.subscriptions) {
+			pending.subscriptions = kept
+			forgotten++
+		}
+	}
+	return forgotten
+}
+
+// describeRemoved formats how many records each store removed, like "profile: 1, subscriptions: 3"
+func describeRemoved(removed map[string]int) string {
+	stores := make([]string, 0, len(removed))
+	for store, n := range removed {
+		if n > 0 {
+			stores = append(stores, fmt.Sprintf("%s: %d", store, n))
+		}
+	}
+	if len(stores) == 0 {
+		return "nothing stored"
+	}
+	sort.Strings(stores)
+	return strings.Join(stores, ", ")
+}
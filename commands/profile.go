@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"discord-bot/data"
+	"discord-bot/notify"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// RegisterProfile registers the profile slash command
+func RegisterProfile() {
+	Register(&SlashCommand{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "profile",
+			Description: "View or change the delivery settings all your subscriptions share",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "show",
+					Description: "Show your profile",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "set",
+					Description: "Change where your notifications go",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "delivery",
+							Description: "How you want to be notified",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "NTFY push", Value: data.DeliveryNTFY},
+								{Name: "Discord DM", Value: data.DeliveryDiscord},
+								{Name: "Webhook", Value: data.DeliveryWebhook},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "ntfy-topic",
+							Description: "Your NTFY topic (e.g., 'john_gaming')",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "webhook-url",
+							Description: "HTTPS URL to POST notifications to",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "priority",
+							Description: "NTFY notification priority",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "1 - Min", Value: notify.PriorityMin},
+								{Name: "2 - Low", Value: notify.PriorityLow},
+								{Name: "3 - Default", Value: notify.PriorityDefault},
+								{Name: "4 - High", Value: notify.PriorityHigh},
+								{Name: "5 - Urgent", Value: notify.PriorityUrgent},
+							},
+						},
+					},
+				},
+			},
+		},
+		Handler: handleProfile,
+	})
+}
+
+// handleProfile handles the profile command
+func handleProfile(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := i.Member.User
+	subcommand := i.ApplicationCommandData().Options[0]
+
+	if subcommand.Name == "set" {
+		handleProfileSet(s, i, subcommand)
+		return
+	}
+
+	respondEphemeral(s, i, describeProfile(Profiles.Get(user.ID)))
+}
+
+// handleProfileSet changes a user's delivery settings, asking them to verify
+// a new NTFY topic before it is used
+func handleProfileSet(s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) {
+	user := i.Member.User
+	profile := Profiles.Get(user.ID)
+
+	// Start from the current settings and apply whatever was given
+	target := profile.Resolve(data.GameSubscription{UserID: user.ID, Username: user.Username})
+	deliveryGiven := false
+	priority := -1
+	for _, option := range subcommand.Options {
+		switch option.Name {
+		case "delivery":
+			target.Delivery = option.StringValue()
+			deliveryGiven = true
+		case "ntfy-topic":
+			target.NTFYTopic = option.StringValue()
+			if !deliveryGiven {
+				target.Delivery = data.DeliveryNTFY
+			}
+		case "webhook-url":
+			target.WebhookURL = option.StringValue()
+			if !deliveryGiven {
+				target.Delivery = data.DeliveryWebhook
+			}
+		case "priority":
+			priority = int(option.IntValue())
+		}
+	}
+
+	if err := validateDelivery(&target); err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("❌ Error: %s", err.Error()))
+		return
+	}
+	verify := needsVerification(target)
+	if verify {
+		if err := allowVerification(user.ID, time.Now()); err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("❌ Error: %s", err.Error()))
+			return
+		}
+	}
+
+	var code string
+	err := Profiles.Update(user.ID, func(profile *data.UserProfile) error {
+		if priority >= 0 {
+			profile.Priority = priority
+		}
+		if verify {
+			// Keep delivering to the old target until the new topic is verified
+			code = newVerifyCode()
+			profile.PendingTopic = target.NTFYTopic
+			profile.VerifyCode = code
+			profile.VerifyExpires = time.Now().Add(verificationTTL)
+			profile.VerifyAttempts = 0
+			return nil
+		}
+		profile.Delivery = target.Delivery
+		profile.NTFYTopic = target.NTFYTopic
+		profile.WebhookURL = target.WebhookURL
+		return nil
+	})
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("❌ Error: %s", err.Error()))
+		return
+	}
+
+	if !verify {
+		respondEphemeral(s, i, describeProfile(Profiles.Get(user.ID)))
+		return
+	}
+
+	// Pushing the code can take a moment, so acknowledge first
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	var content string
+	if err := pushVerificationCode(target, code); err != nil {
+		Profiles.Update(user.ID, func(profile *data.UserProfile) error {
+			profile.PendingTopic = ""
+			profile.VerifyCode = ""
+			profile.VerifyExpires = time.Time{}
+			profile.VerifyAttempts = 0
+			return nil
+		})
+		content = fmt.Sprintf("❌ Error: couldn't send a verification code to `%s`: %s", target.NTFYTopic, err.Error())
+	} else {
+		content = fmt.Sprintf("📨 We pushed a verification code to NTFY topic `%s`.\n"+
+			"Run `/verify <code>` within %d minutes to start using it.", target.NTFYTopic, int(verificationTTL.Minutes()))
+	}
+
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	})
+}
+
+// describeProfile formats a user's delivery settings
+func describeProfile(profile data.UserProfile) string {
+	var response strings.Builder
+	response.WriteString("👤 **Your Profile:**\n\n")
+
+	response.WriteString(fmt.Sprintf("Delivery: %s\n", deliveryLabel(profile.Resolve(data.GameSubscription{UserID: profile.UserID}))))
+	if profile.Priority != 0 {
+		response.WriteString(fmt.Sprintf("Priority: %d\n", profile.Priority))
+	}
+	if profile.PendingTopic != "" {
+		response.WriteString(fmt.Sprintf("⏳ NTFY topic `%s` is waiting for `/verify`\n", profile.PendingTopic))
+	}
+
+	response.WriteString("\nSubscriptions without their own delivery options use these settings. ")
+	response.WriteString("Use `/quiet-hours` to choose when you're notified.")
+	return response.String()
+}
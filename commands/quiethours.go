@@ -0,0 +1,195 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"discord-bot/data"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Global profile manager - initialized in main
+var Profiles *data.ProfileManager
+
+// Whether the bot receives presences, without them the DND check can't work - set in bot.New
+var PresenceEnabled bool
+
+// RegisterQuietHours registers the quiet-hours slash command
+func RegisterQuietHours() {
+	Register(&SlashCommand{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "quiet-hours",
+			Description: "Set times when you don't want to be notified",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "show",
+					Description: "Show your time zone and quiet hours",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Add a quiet hours window",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "start",
+							Description: "When quiet hours start (e.g. 23:00)",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "end",
+							Description: "When quiet hours end (e.g. 07:30)",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "clear",
+					Description: "Remove all your quiet hours",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "timezone",
+					Description: "Set your time zone",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "zone",
+							Description: "IANA time zone name (e.g. Europe/Oslo, America/New_York)",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "mode",
+					Description: "Choose what happens to notifications during quiet hours",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "mode",
+							Description: "Drop them or get a digest when quiet hours end",
+							Required:    true,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Drop", Value: data.QuietDrop},
+								{Name: "Morning digest", Value: data.QuietDigest},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "dnd",
+					Description: "Choose whether to skip notifications while your Discord status is Do Not Disturb",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "skip",
+							Description: "Skip notifications while you're on Do Not Disturb",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		Handler: handleQuietHours,
+	})
+}
+
+// handleQuietHours handles the quiet-hours command
+func handleQuietHours(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := i.Member.User
+	subcommand := i.ApplicationCommandData().Options[0]
+
+	options := make(map[string]string)
+	for _, option := range subcommand.Options {
+		if option.Type == discordgo.ApplicationCommandOptionString {
+			options[option.Name] = option.StringValue()
+		}
+	}
+
+	var err error
+	switch subcommand.Name {
+	case "add":
+		err = Profiles.Update(user.ID, func(profile *data.UserProfile) error {
+			window, err := data.ParseTimeWindow(options["start"], options["end"])
+			if err != nil {
+				return err
+			}
+			profile.QuietHours = append(profile.QuietHours, window)
+			return nil
+		})
+	case "clear":
+		err = Profiles.Update(user.ID, func(profile *data.UserProfile) error {
+			profile.QuietHours = nil
+			return nil
+		})
+	case "timezone":
+		err = Profiles.Update(user.ID, func(profile *data.UserProfile) error {
+			if _, err := time.LoadLocation(options["zone"]); err != nil {
+				return fmt.Errorf("unknown time zone %s", options["zone"])
+			}
+			profile.TimeZone = options["zone"]
+			return nil
+		})
+	case "mode":
+		err = Profiles.Update(user.ID, func(profile *data.UserProfile) error {
+			profile.QuietMode = options["mode"]
+			return nil
+		})
+	case "dnd":
+		err = Profiles.Update(user.ID, func(profile *data.UserProfile) error {
+			skip := subcommand.Options[0].BoolValue()
+			if skip && !PresenceEnabled {
+				return fmt.Errorf("the bot can't see Discord statuses here, ask the bot owner to set ENABLE_PRESENCE_INTENT")
+			}
+			profile.SkipWhenDND = skip
+			return nil
+		})
+	}
+
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("❌ Error: %s", err.Error()))
+		return
+	}
+
+	respondEphemeral(s, i, describeQuietHours(Profiles.Get(user.ID)))
+}
+
+// describeQuietHours formats a user's quiet hours settings
+func describeQuietHours(profile data.UserProfile) string {
+	var response strings.Builder
+	response.WriteString("🌙 **Your Quiet Hours:**\n\n")
+
+	timeZone := profile.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC (use `/quiet-hours timezone` to change)"
+	}
+	response.WriteString(fmt.Sprintf("Time zone: %s\n", timeZone))
+	if profile.SkipWhenDND && PresenceEnabled {
+		response.WriteString("Do Not Disturb: notifications are skipped while your status is DND\n")
+	}
+
+	if len(profile.QuietHours) == 0 {
+		response.WriteString("No quiet hours set, you'll be notified any time.")
+		return response.String()
+	}
+
+	windows := make([]string, 0, len(profile.QuietHours))
+	for _, window := range profile.QuietHours {
+		windows = append(windows, fmt.Sprintf("`%s`", window))
+	}
+	response.WriteString(fmt.Sprintf("Quiet hours: %s\n", strings.Join(windows, ", ")))
+
+	if profile.QuietHoursMode() == data.QuietDigest {
+		response.WriteString("Notifications during quiet hours are saved for a digest when they end.")
+	} else {
+		response.WriteString("Notifications during quiet hours are dropped.")
+	}
+	return response.String()
+}
@@ -2,67 +2,98 @@ package commands
 
 import (
 	"fmt"
+	"log"
+	"net/url"
+	"sort"
 	"strings"
+	"time"
 
 	"discord-bot/data"
+	"discord-bot/notify"
 
 	"github.com/bwmarrin/discordgo"
 )
 
-// Common games list - you can expand this
-var commonGames = []string{
-	"valorant", "csgo", "cs2", "overwatch", "apex", "fortnite",
-	"minecraft", "rocket-league", "cod", "warzone", "dota2", "lol",
-	"among-us", "fall-guys", "gta", "rust", "destiny2", "wow",
-}
-
 // Global subscription manager - you'll initialize this in main
 var SubManager *data.SubscriptionManager
 
-// Simple title case function
-func toTitleCase(s string) string {
-	words := strings.Fields(s)
-	for i, word := range words {
-		if len(word) > 0 {
-			words[i] = strings.ToUpper(word[:1]) + word[1:]
-		}
-	}
-	return strings.Join(words, " ")
-}
+// Global game catalog - initialized in main
+var Games *data.GameCatalog
 
 // RegisterSubscribe registers the subscribe slash command
 func RegisterSubscribe() {
-	// Create choices for common games
-	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(commonGames))
-	for _, game := range commonGames {
-		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
-			Name:  toTitleCase(strings.ReplaceAll(game, "-", " ")),
-			Value: game,
-		})
-	}
+	minPlayers := float64(1)
+	maxPlayers := float64(10)
 
 	Register(&SlashCommand{
 		Definition: &discordgo.ApplicationCommand{
 			Name:        "subscribe",
-			Description: "Subscribe to notifications for a game",
+			Description: "Subscribe to notifications for a game, or leave out the game to pick several",
 			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "game",
+					Description:  "The game you want notifications for (leave out to pick from a list)",
+					Required:     false,
+					Autocomplete: true,
+				},
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "game",
-					Description: "The game you want notifications for",
-					Required:    true,
-					Choices:     choices,
+					Name:        "delivery",
+					Description: "How you want to be notified about this game (default: your /profile settings)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "NTFY push", Value: data.DeliveryNTFY},
+						{Name: "Discord DM", Value: data.DeliveryDiscord},
+						{Name: "Webhook", Value: data.DeliveryWebhook},
+					},
 				},
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
 					Name:        "ntfy-topic",
 					Description: "Your NTFY topic (e.g., 'john_gaming')",
-					Required:    true,
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "webhook-url",
+					Description: "HTTPS URL to POST notifications to",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "priority",
+					Description: "NTFY notification priority (default: your /profile setting)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "1 - Min", Value: notify.PriorityMin},
+						{Name: "2 - Low", Value: notify.PriorityLow},
+						{Name: "3 - Default", Value: notify.PriorityDefault},
+						{Name: "4 - High", Value: notify.PriorityHigh},
+						{Name: "5 - Urgent", Value: notify.PriorityUrgent},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "min-players",
+					Description: "Only notify me once this many people are waiting (default: 1)",
+					Required:    false,
+					MinValue:    &minPlayers,
+					MaxValue:    maxPlayers,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "duration",
+					Description: "Stop notifying me after this long, like 3d, 12h or 1w (default: forever)",
+					Required:    false,
 				},
 			},
 		},
-		Handler: handleSubscribe,
+		Handler:      handleSubscribe,
+		Autocomplete: autocompleteGames,
 	})
+	RegisterComponent("pick-games", handleGamePickerSelect)
+	RegisterComponent("renew", handleRenewButton)
 }
 
 // RegisterUnsubscribe registers the unsubscribe slash command
@@ -77,11 +108,12 @@ func RegisterUnsubscribe() {
 					Name:         "game",
 					Description:  "The game to unsubscribe from",
 					Required:     true,
-					Autocomplete: true, // We'll make this show user's subscriptions
+					Autocomplete: true,
 				},
 			},
 		},
-		Handler: handleUnsubscribe,
+		Handler:      handleUnsubscribe,
+		Autocomplete: autocompleteSubscriptions,
 	})
 }
 
@@ -90,7 +122,7 @@ func RegisterMyGames() {
 	Register(&SlashCommand{
 		Definition: &discordgo.ApplicationCommand{
 			Name:        "mygames",
-			Description: "See what games you're subscribed to",
+			Description: "See what games you're subscribed to in this server",
 		},
 		Handler: handleMyGames,
 	})
@@ -101,7 +133,7 @@ func RegisterGamesList() {
 	Register(&SlashCommand{
 		Definition: &discordgo.ApplicationCommand{
 			Name:        "games",
-			Description: "See all games people are subscribed to",
+			Description: "See all games people in this server are subscribed to",
 		},
 		Handler: handleGamesList,
 	})
@@ -109,19 +141,63 @@ func RegisterGamesList() {
 
 // handleSubscribe handles the subscribe command
 func handleSubscribe(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	var game, ntfyTopic string
+	user := i.Member.User
+	sub := data.GameSubscription{
+		GuildID:  i.GuildID,
+		UserID:   user.ID,
+		Username: user.Username,
+	}
+	duration := ""
 
 	for _, option := range i.ApplicationCommandData().Options {
 		switch option.Name {
 		case "game":
-			game = option.StringValue()
+			sub.Game = option.StringValue()
+		case "delivery":
+			sub.Delivery = option.StringValue()
 		case "ntfy-topic":
-			ntfyTopic = option.StringValue()
+			sub.NTFYTopic = option.StringValue()
+		case "webhook-url":
+			sub.WebhookURL = option.StringValue()
+		case "priority":
+			sub.Priority = int(option.IntValue())
+		case "min-players":
+			sub.MinPlayers = int(option.IntValue())
+		case "duration":
+			duration = option.StringValue()
 		}
 	}
 
-	user := i.Member.User
-	err := SubManager.Subscribe(user.ID, user.Username, game, ntfyTopic)
+	if sub.Game == "" {
+		handleGamePicker(s, i)
+		return
+	}
+
+	// Accept aliases and names as well as IDs, e.g. "csgo" for "cs2"
+	var err error
+	if game, exists := Games.Resolve(sub.Game); exists {
+		sub.Game = game.ID
+	} else {
+		err = fmt.Errorf("%s isn't in the game list, see `/games` or ask an admin to add it", sub.Game)
+	}
+
+	if err == nil && duration != "" {
+		err = limitSubscription(&sub, duration, time.Now())
+	}
+
+	// Without any delivery options the subscription uses the user's profile
+	if err == nil && !sub.UsesProfile() {
+		err = validateDelivery(&sub)
+	}
+	if err == nil && !sub.UsesProfile() && needsVerification(sub) {
+		sub.Pending = true
+		sub.VerifyCode = newVerifyCode()
+		sub.VerifyExpires = time.Now().Add(verificationTTL)
+		err = allowVerification(user.ID, time.Now())
+	}
+	if err == nil {
+		err = SubManager.Subscribe(sub)
+	}
 	if err != nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -133,10 +209,23 @@ func handleSubscribe(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 
-	gameName := toTitleCase(strings.ReplaceAll(game, "-", " "))
-	response := fmt.Sprintf("✅ Successfully subscribed to **%s** notifications!\n"+
-		"NTFY Topic: `%s`\n"+
-		"You'll get notified when someone wants to play!", gameName, ntfyTopic)
+	warning := flushSubscriptions()
+
+	if sub.Pending {
+		sendVerification(s, i, sub)
+		return
+	}
+
+	response := Templates.Render(i.GuildID, data.TemplateSubscribed, data.TemplateVars{
+		User:     user.Username,
+		Game:     Games.DisplayName(sub.Game),
+		GameID:   sub.Game,
+		Delivery: deliveryLabel(Profiles.Get(user.ID).Resolve(sub)),
+	})
+	if expiry := describeExpiry(sub); expiry != "" {
+		response += "\n" + expiry
+	}
+	response += warning
 
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -149,10 +238,13 @@ func handleSubscribe(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 // handleUnsubscribe handles the unsubscribe command
 func handleUnsubscribe(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	game := i.ApplicationCommandData().Options[0].StringValue()
+	game := data.NormalizeGameID(i.ApplicationCommandData().Options[0].StringValue())
+	if entry, exists := Games.Resolve(game); exists {
+		game = entry.ID
+	}
 	user := i.Member.User
 
-	err := SubManager.Unsubscribe(user.ID, game)
+	err := SubManager.Unsubscribe(i.GuildID, user.ID, game)
 	if err != nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -164,8 +256,11 @@ func handleUnsubscribe(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 
-	gameName := toTitleCase(strings.ReplaceAll(game, "-", " "))
-	response := fmt.Sprintf("✅ Successfully unsubscribed from **%s** notifications!", gameName)
+	response := Templates.Render(i.GuildID, data.TemplateUnsubscribed, data.TemplateVars{
+		User:   user.Username,
+		Game:   Games.DisplayName(game),
+		GameID: game,
+	}) + flushSubscriptions()
 
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -176,10 +271,34 @@ func handleUnsubscribe(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	})
 }
 
+// flushSubscriptions saves a change a user just made, so they hear about it
+// if it couldn't be stored. Returns a warning to add to the reply, or "".
+func flushSubscriptions() string {
+	if err := SubManager.Flush(); err != nil {
+		log.Printf("Error saving subscriptions: %v", err)
+		return "\n⚠️ This change couldn't be saved yet and will be retried, it could be lost if the bot restarts before then."
+	}
+	return ""
+}
+
+// autocompleteSubscriptions suggests the games the user is subscribed to in this server
+func autocompleteSubscriptions(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var suggestions []suggestion
+	for _, sub := range SubManager.GetSubscriptions(i.GuildID, i.Member.User.ID) {
+		// Games removed from the catalog can still be unsubscribed from
+		game, exists := Games.Resolve(sub.Game)
+		if !exists {
+			game = data.Game{ID: sub.Game, Name: Games.DisplayName(sub.Game)}
+		}
+		suggestions = append(suggestions, gameSuggestion(game))
+	}
+	respondAutocomplete(s, i, rankSuggestions(focusedValue(i), suggestions))
+}
+
 // handleMyGames handles the mygames command
 func handleMyGames(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	user := i.Member.User
-	subscriptions := SubManager.GetSubscriptions(user.ID)
+	subscriptions := SubManager.GetSubscriptions(i.GuildID, user.ID)
 
 	if len(subscriptions) == 0 {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -192,11 +311,29 @@ func handleMyGames(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 
+	profile := Profiles.Get(user.ID)
+
 	var response strings.Builder
 	response.WriteString("📱 **Your Game Subscriptions:**\n\n")
 	for _, sub := range subscriptions {
-		gameName := toTitleCase(strings.ReplaceAll(sub.Game, "-", " "))
-		response.WriteString(fmt.Sprintf("🎮 **%s** → `%s`\n", gameName, sub.NTFYTopic))
+		gameName := Games.DisplayName(sub.Game)
+		response.WriteString(fmt.Sprintf("🎮 **%s** → %s", gameName, deliveryLabel(profile.Resolve(sub))))
+		if sub.UsesProfile() {
+			response.WriteString(" (from `/profile`)")
+		}
+		if sub.MinPlayers > 1 {
+			response.WriteString(fmt.Sprintf(" (once %d+ are waiting)", sub.MinPlayers))
+		}
+		if len(sub.Availability) > 0 {
+			response.WriteString(" 🕒 " + describeWindows(sub.Availability))
+		}
+		if expiry := describeExpiry(sub); expiry != "" {
+			response.WriteString(" " + expiry)
+		}
+		if sub.Pending {
+			response.WriteString(" ⏳ *waiting for `/verify`*")
+		}
+		response.WriteString("\n")
 	}
 
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -210,9 +347,9 @@ func handleMyGames(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 // handleGamesList handles the games command
 func handleGamesList(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	games := SubManager.GetAllGames()
+	counts := SubManager.CountByGame(i.GuildID)
 
-	if len(games) == 0 {
+	if len(counts) == 0 {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -224,10 +361,14 @@ func handleGamesList(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	var response strings.Builder
 	response.WriteString("🎮 **Games with subscribers:**\n\n")
+	games := make([]string, 0, len(counts))
+	for game := range counts {
+		games = append(games, game)
+	}
+	sort.Strings(games)
 	for _, game := range games {
-		subscribers := SubManager.GetSubscribersForGame(game)
-		gameName := toTitleCase(strings.ReplaceAll(game, "-", " "))
-		response.WriteString(fmt.Sprintf("**%s** (%d subscribers)\n", gameName, len(subscribers)))
+		gameName := Games.DisplayName(game)
+		response.WriteString(fmt.Sprintf("**%s** (%d subscribers)\n", gameName, counts[game]))
 	}
 
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -237,3 +378,52 @@ func handleGamesList(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		},
 	})
 }
+
+// validateDelivery picks a default delivery method and checks the subscription has what it needs
+func validateDelivery(sub *data.GameSubscription) error {
+	if sub.Delivery == "" {
+		switch {
+		case sub.NTFYTopic != "":
+			sub.Delivery = data.DeliveryNTFY
+		case sub.WebhookURL != "":
+			sub.Delivery = data.DeliveryWebhook
+		default:
+			sub.Delivery = data.DeliveryDiscord
+		}
+	}
+
+	switch sub.Delivery {
+	case data.DeliveryNTFY:
+		if sub.NTFYTopic == "" {
+			return fmt.Errorf("NTFY delivery needs an `ntfy-topic`")
+		}
+		sub.WebhookURL = ""
+	case data.DeliveryWebhook:
+		if err := notify.ValidateWebhookURL(sub.WebhookURL); err != nil {
+			return err
+		}
+		sub.NTFYTopic = ""
+	case data.DeliveryDiscord:
+		sub.NTFYTopic = ""
+		sub.WebhookURL = ""
+	default:
+		return fmt.Errorf("unknown delivery method %s", sub.Delivery)
+	}
+	return nil
+}
+
+// deliveryLabel describes where a subscription's notifications go
+func deliveryLabel(sub data.GameSubscription) string {
+	switch sub.DeliveryMethod() {
+	case data.DeliveryDiscord:
+		return "Discord DM"
+	case data.DeliveryWebhook:
+		// Only show the host, webhook URLs often embed secrets
+		if u, err := url.Parse(sub.WebhookURL); err == nil {
+			return fmt.Sprintf("Webhook `%s`", u.Host)
+		}
+		return "Webhook"
+	default:
+		return fmt.Sprintf("NTFY `%s`", sub.NTFYTopic)
+	}
+}
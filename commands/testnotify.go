@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-bot/data"
+	"discord-bot/notify"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Global notification dispatcher - initialized in main
+var Notifier *notify.Dispatcher
+
+// deliveryTest is the outcome of a test notification to one delivery target
+type deliveryTest struct {
+	sub     data.GameSubscription
+	games   []string
+	status  int
+	latency time.Duration
+	err     error
+}
+
+// RegisterTestNotify registers the testnotify slash command
+func RegisterTestNotify() {
+	Register(&SlashCommand{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "testnotify",
+			Description: "Send a test notification through each of your delivery channels",
+		},
+		Handler: handleTestNotify,
+	})
+}
+
+// handleTestNotify handles the testnotify command
+func handleTestNotify(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := i.Member.User
+
+	// Unverified topics never get anything but their verification code
+	var subscriptions []data.GameSubscription
+	unverified := 0
+	for _, sub := range SubManager.GetSubscriptions(i.GuildID, user.ID) {
+		if sub.Pending {
+			unverified++
+			continue
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+
+	if len(subscriptions) == 0 && unverified > 0 {
+		respondEphemeral(s, i, "⏳ Your subscriptions are waiting for `/verify`, test notifications are sent once the topic is verified.")
+		return
+	}
+	if len(subscriptions) == 0 {
+		respondEphemeral(s, i, "📱 You're not subscribed to any games yet!\nUse `/subscribe` to get started.")
+		return
+	}
+
+	// Deliveries can take a while, so acknowledge first and report back after
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	// Test where notifications really go, including settings from the profile
+	profile := Profiles.Get(user.ID)
+	for idx, sub := range subscriptions {
+		subscriptions[idx] = profile.Resolve(sub)
+	}
+
+	tests := groupByTarget(subscriptions)
+	msg := notify.Message{
+		Title: "🧪 Test notification",
+		Body:  fmt.Sprintf("Hi %s! If you can read this, LFG notifications will reach you here.", user.Username),
+		Tags:  []string{"test_tube"},
+	}
+
+	var wg sync.WaitGroup
+	for _, test := range tests {
+		wg.Add(1)
+		go func(test *deliveryTest) {
+			defer wg.Done()
+			start := time.Now()
+			test.status, test.err = Notifier.Send(test.sub, msg)
+			test.latency = time.Since(start)
+		}(test)
+	}
+	wg.Wait()
+
+	for _, test := range tests {
+		recordTest(i.GuildID, test)
+	}
+
+	var response strings.Builder
+	response.WriteString("🧪 **Test Notification Results:**\n\n")
+	for _, test := range tests {
+		result := "✅"
+		if test.err != nil {
+			result = "❌"
+		}
+		response.WriteString(fmt.Sprintf("%s %s (%s)\n", result, deliveryLabel(test.sub), strings.Join(test.games, ", ")))
+
+		details := fmt.Sprintf("%dms", test.latency.Milliseconds())
+		if test.status != 0 {
+			details = fmt.Sprintf("HTTP %d %s, %s", test.status, http.StatusText(test.status), details)
+		}
+		response.WriteString(fmt.Sprintf("└ %s\n", details))
+		if test.err != nil {
+			response.WriteString(fmt.Sprintf("└ `%s`\n", test.err.Error()))
+		}
+	}
+
+	if unverified > 0 {
+		response.WriteString(fmt.Sprintf("\n⏳ Skipped %d subscription(s) waiting for `/verify`.\n", unverified))
+	}
+
+	content := response.String()
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	})
+}
+
+// groupByTarget collapses subscriptions that deliver to the same place, so
+// each delivery channel only gets one test
+func groupByTarget(subscriptions []data.GameSubscription) []*deliveryTest {
+	var tests []*deliveryTest
+	byTarget := make(map[string]*deliveryTest)
+
+	for _, sub := range subscriptions {
+		gameName := Games.DisplayName(sub.Game)
+		key := sub.DeliveryMethod() + ":" + sub.Target()
+
+		if test, exists := byTarget[key]; exists {
+			test.games = append(test.games, gameName)
+			continue
+		}
+
+		test := &deliveryTest{sub: sub, games: []string{gameName}}
+		byTarget[key] = test
+		tests = append(tests, test)
+	}
+	return tests
+}
+
+// recordTest adds a test notification to the history log
+func recordTest(guildID string, test *deliveryTest) {
+	entry := data.HistoryEntry{
+		GuildID:  guildID,
+		UserID:   test.sub.UserID,
+		Username: test.sub.Username,
+		Backend:  test.sub.DeliveryMethod(),
+		Outcome:  data.OutcomeSent,
+		Reason:   "/testnotify",
+	}
+	if test.err != nil {
+		entry.Outcome = data.OutcomeFailed
+		entry.Reason = fmt.Sprintf("/testnotify: %s", test.err.Error())
+	}
+
+	if err := History.Record(entry); err != nil {
+		log.Printf("Error recording notification history: %v", err)
+	}
+}
@@ -0,0 +1,389 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-bot/data"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxImportSize is the largest attachment accepted by import-subscriptions
+	maxImportSize = 1 << 20
+
+	// importTTL is how long an import preview can be confirmed
+	importTTL = 15 * time.Minute
+
+	// previewLines is how many subscriptions of each kind the preview lists
+	previewLines = 5
+)
+
+// pendingImport is an import waiting for the admin to confirm it
+type pendingImport struct {
+	guildID       string
+	adminID       string
+	subscriptions []data.GameSubscription
+	replace       bool
+	expires       time.Time
+}
+
+var (
+	pendingImports     = make(map[string]*pendingImport)
+	pendingImportMutex sync.Mutex
+)
+
+// attachmentClient downloads uploaded import files
+var attachmentClient = &http.Client{Timeout: 10 * time.Second}
+
+// handleAdminExport replies with the guild's subscriptions as a file
+func handleAdminExport(s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) {
+	format := "json"
+	for _, option := range subcommand.Options {
+		if option.Name == "format" {
+			format = option.StringValue()
+		}
+	}
+
+	subscriptions := SubManager.GetGuildSubscriptions(i.GuildID)
+
+	var contents []byte
+	var err error
+	contentType := "application/json"
+	if format == "csv" {
+		contents, err = data.EncodeSubscriptionsCSV(subscriptions)
+		contentType = "text/csv"
+	} else {
+		contents, err = data.EncodeSubscriptionsJSON(subscriptions)
+	}
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("❌ Error: %s", err.Error()))
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("📤 Exported %d subscription(s).", len(subscriptions)),
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Files: []*discordgo.File{
+				{
+					Name:        fmt.Sprintf("subscriptions-%s.%s", i.GuildID, format),
+					ContentType: contentType,
+					Reader:      bytes.NewReader(contents),
+				},
+			},
+		},
+	})
+}
+
+// handleAdminImport validates an uploaded file and shows what importing it
+// would change, with buttons to confirm or cancel
+func handleAdminImport(s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) {
+	var attachment *discordgo.MessageAttachment
+	replace := false
+	for _, option := range subcommand.Options {
+		switch option.Name {
+		case "file":
+			attachment = i.ApplicationCommandData().Resolved.Attachments[option.Value.(string)]
+		case "mode":
+			replace = option.StringValue() == "replace"
+		}
+	}
+	if attachment == nil {
+		respondEphemeral(s, i, "❌ Error: attach a JSON or CSV file to import")
+		return
+	}
+	if attachment.Size > maxImportSize {
+		respondEphemeral(s, i, fmt.Sprintf("❌ Error: the file is larger than %d KB", maxImportSize/1024))
+		return
+	}
+
+	// Downloading and checking the file can take a moment, so acknowledge first
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	subscriptions, err := readImport(attachment)
+	if err != nil {
+		content := fmt.Sprintf("❌ Error: %s", err.Error())
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+		return
+	}
+
+	subscriptions, skipped := keepGuildMembers(s, i.GuildID, subscriptions)
+	changes := SubManager.Diff(i.GuildID, subscriptions, replace)
+	if changes.Empty() {
+		content := "📥 Nothing to import, the file matches this server's subscriptions." + describeSkipped(skipped)
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+		return
+	}
+
+	id := newImportID()
+	pendingImportMutex.Lock()
+	removeExpiredImports(time.Now())
+	pendingImports[id] = &pendingImport{
+		guildID:       i.GuildID,
+		adminID:       i.Member.User.ID,
+		subscriptions: subscriptions,
+		replace:       replace,
+		expires:       time.Now().Add(importTTL),
+	}
+	pendingImportMutex.Unlock()
+
+	content := describeImport(attachment.Filename, changes, replace, skipped)
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Import", Style: discordgo.SuccessButton, CustomID: "import:confirm:" + id},
+				discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "import:cancel:" + id},
+			},
+		},
+	}
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    &content,
+		Components: &components,
+	})
+}
+
+// handleImportButton applies or cancels a previewed import
+func handleImportButton(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if len(args) != 2 {
+		return
+	}
+	action, id := args[0], args[1]
+
+	pendingImportMutex.Lock()
+	pending, exists := pendingImports[id]
+	if exists && pending.adminID == i.Member.User.ID {
+		delete(pendingImports, id)
+	}
+	pendingImportMutex.Unlock()
+
+	var content string
+	switch {
+	case !exists || time.Now().After(pending.expires):
+		content = "⌛ This import expired, run `/admin import-subscriptions` again."
+	case pending.adminID != i.Member.User.ID:
+		respondEphemeral(s, i, "❌ Error: only the admin who started this import can confirm it")
+		return
+	case action == "cancel":
+		content = "🚫 Import cancelled."
+	default:
+		// Work the changes out again in case subscriptions or members changed since the preview
+		subscriptions, skipped := keepGuildMembers(s, pending.guildID, pending.subscriptions)
+		changes := SubManager.Diff(pending.guildID, subscriptions, pending.replace)
+		if err := SubManager.Apply(changes); err != nil {
+			content = fmt.Sprintf("❌ Error: import failed, nothing was changed: %s", err.Error())
+		} else {
+			content = fmt.Sprintf("📥 Imported! %d added, %d updated, %d removed.",
+				len(changes.Added), len(changes.Updated), len(changes.Removed)) + describeSkipped(skipped)
+			fmt.Printf("📥 %s imported subscriptions into %s\n", i.Member.User.Username, pending.guildID)
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
+
+// readImport downloads an attachment and checks every subscription in it
+func readImport(attachment *discordgo.MessageAttachment) ([]data.GameSubscription, error) {
+	resp, err := attachmentClient.Get(attachment.URL)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't download the file: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("couldn't download the file: %s", resp.Status)
+	}
+
+	contents, err := io.ReadAll(io.LimitReader(resp.Body, maxImportSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't download the file: %v", err)
+	}
+	if len(contents) > maxImportSize {
+		return nil, fmt.Errorf("the file is larger than %d KB", maxImportSize/1024)
+	}
+
+	var subscriptions []data.GameSubscription
+	if strings.EqualFold(path.Ext(attachment.Filename), ".csv") {
+		subscriptions, err = data.DecodeSubscriptionsCSV(contents)
+	} else {
+		subscriptions, err = data.DecodeSubscriptionsJSON(contents)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	seen := make(map[string]int)
+	for n := range subscriptions {
+		// Row numbers count from 1, like a spreadsheet without its header
+		row := n + 1
+		if err := validateImported(&subscriptions[n]); err != nil {
+			problems = append(problems, fmt.Sprintf("row %d: %s", row, err.Error()))
+			continue
+		}
+		key := subscriptions[n].UserID + "|" + subscriptions[n].Game
+		if first, exists := seen[key]; exists {
+			problems = append(problems, fmt.Sprintf("row %d: duplicate of row %d", row, first))
+			continue
+		}
+		seen[key] = row
+	}
+
+	if len(problems) > 0 {
+		if len(problems) > 10 {
+			problems = append(problems[:10], fmt.Sprintf("...and %d more", len(problems)-10))
+		}
+		return nil, fmt.Errorf("the file has problems, nothing was imported:\n%s", strings.Join(problems, "\n"))
+	}
+	return subscriptions, nil
+}
+
+// validateImported checks an imported subscription the same way /subscribe would
+func validateImported(sub *data.GameSubscription) error {
+	if sub.UserID == "" || strings.Trim(sub.UserID, "0123456789") != "" {
+		return fmt.Errorf("invalid user_id %q", sub.UserID)
+	}
+	if sub.Game == "" {
+		return fmt.Errorf("missing game")
+	}
+	game, exists := Games.Resolve(sub.Game)
+	if !exists {
+		return fmt.Errorf("%s isn't in the game list", sub.Game)
+	}
+	sub.Game = game.ID
+	if sub.Priority < 0 || sub.Priority > 5 {
+		return fmt.Errorf("priority must be empty or between 1 and 5")
+	}
+	if sub.MinPlayers < 0 || sub.MinPlayers > 10 {
+		return fmt.Errorf("min_players must be empty or between 1 and 10")
+	}
+	if sub.Duration != "" {
+		if _, err := parseSubscriptionDuration(sub.Duration); err != nil {
+			return err
+		}
+	}
+
+	// Verification state isn't imported, an NTFY topic has to be one the
+	// user already proved they own
+	sub.Pending = false
+	sub.VerifyCode = ""
+	sub.VerifyExpires = time.Time{}
+	sub.VerifyAttempts = 0
+
+	if sub.UsesProfile() {
+		return nil
+	}
+	if err := validateDelivery(sub); err != nil {
+		return err
+	}
+	if needsVerification(*sub) {
+		return fmt.Errorf("NTFY topic %s isn't verified for user %s, they need to `/subscribe` with it first", sub.NTFYTopic, sub.UserID)
+	}
+	return nil
+}
+
+// keepGuildMembers splits imported subscriptions into those of the guild's
+// members and those of users who aren't in it, so an import can't subscribe
+// strangers to notifications
+func keepGuildMembers(s *discordgo.Session, guildID string, subscriptions []data.GameSubscription) (kept, skipped []data.GameSubscription) {
+	members := make(map[string]bool)
+	for _, sub := range subscriptions {
+		member, checked := members[sub.UserID]
+		if !checked {
+			member = isGuildMember(s, guildID, sub.UserID)
+			members[sub.UserID] = member
+		}
+		if member {
+			kept = append(kept, sub)
+		} else {
+			skipped = append(skipped, sub)
+		}
+	}
+	return kept, skipped
+}
+
+// describeSkipped lists the users whose imported subscriptions were skipped
+// because they aren't in the server, or returns "" if there were none
+func describeSkipped(skipped []data.GameSubscription) string {
+	if len(skipped) == 0 {
+		return ""
+	}
+	var users []string
+	seen := make(map[string]bool)
+	for _, sub := range skipped {
+		if !seen[sub.UserID] {
+			seen[sub.UserID] = true
+			users = append(users, fmt.Sprintf("<@%s>", sub.UserID))
+		}
+	}
+	if len(users) > previewLines {
+		users = append(users[:previewLines], fmt.Sprintf("…and %d more", len(users)-previewLines))
+	}
+	return fmt.Sprintf("\n⚠️ Skipped %d subscription(s) of users who aren't in this server: %s",
+		len(skipped), strings.Join(users, ", "))
+}
+
+// describeImport formats the preview of an import
+func describeImport(filename string, changes data.SubscriptionChanges, replace bool, skipped []data.GameSubscription) string {
+	mode := "merge"
+	if replace {
+		mode = "replace"
+	}
+
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("📥 **Import Preview** `%s` (%s)\n\n", filename, mode))
+	writeChanges := func(symbol, label string, subs []data.GameSubscription) {
+		response.WriteString(fmt.Sprintf("%s %s: **%d**\n", symbol, label, len(subs)))
+		for n, sub := range subs {
+			if n == previewLines {
+				response.WriteString(fmt.Sprintf("  …and %d more\n", len(subs)-previewLines))
+				break
+			}
+			gameName := Games.DisplayName(sub.Game)
+			response.WriteString(fmt.Sprintf("  • <@%s> %s\n", sub.UserID, gameName))
+		}
+	}
+	writeChanges("➕", "Added", changes.Added)
+	writeChanges("✏️", "Updated", changes.Updated)
+	writeChanges("➖", "Removed", changes.Removed)
+	response.WriteString(describeSkipped(skipped))
+	response.WriteString(fmt.Sprintf("\nConfirm within %d minutes.", int(importTTL.Minutes())))
+	return response.String()
+}
+
+// removeExpiredImports forgets previews that can no longer be confirmed. Must
+// hold pendingImportMutex.
+func removeExpiredImports(now time.Time) {
+	for id, pending := range pendingImports {
+		if now.After(pending.expires) {
+			delete(pendingImports, id)
+		}
+	}
+}
+
+// newImportID returns a random ID for a pending import
+func newImportID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-bot/data"
+	"discord-bot/notify"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// verificationTTL is how long a new NTFY topic has to be verified before the
+// subscription is dropped
+const verificationTTL = 30 * time.Minute
+
+// verificationCooldown is how long a user waits between verification codes,
+// so the bot can't be used to flood someone else's NTFY topic
+const verificationCooldown = time.Minute
+
+var (
+	lastVerification      = make(map[string]time.Time) // User ID -> when a code was last pushed for them
+	lastVerificationMutex sync.Mutex
+)
+
+// RegisterVerify registers the verify slash command
+func RegisterVerify() {
+	Register(&SlashCommand{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "verify",
+			Description: "Confirm your NTFY topic with the code that was pushed to it",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "code",
+					Description: "The code from the notification",
+					Required:    true,
+				},
+			},
+		},
+		Handler: handleVerify,
+	})
+}
+
+// handleVerify handles the verify command
+func handleVerify(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := i.Member.User
+	code := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+
+	// The code may be for pending subscriptions, a new profile topic, or both
+	verified, subErr := SubManager.Verify(user.ID, code)
+	topic, profileErr := Profiles.VerifyTopic(user.ID, code, time.Now())
+	if subErr != nil && profileErr != nil {
+		respondEphemeral(s, i, failVerification(user.ID))
+		return
+	}
+
+	var response strings.Builder
+	response.WriteString("✅ Verified!")
+	if len(verified) > 0 {
+		games := make([]string, 0, len(verified))
+		for _, sub := range verified {
+			games = append(games, fmt.Sprintf("**%s**", Games.DisplayName(sub.Game)))
+		}
+		response.WriteString(fmt.Sprintf(" You'll now get %s notifications.", strings.Join(games, ", ")))
+	}
+	if profileErr == nil {
+		response.WriteString(fmt.Sprintf(" Your profile now delivers to NTFY topic `%s`.", topic))
+	}
+	respondEphemeral(s, i, response.String())
+}
+
+// failVerification counts a wrong code against everything the user has
+// waiting for /verify and returns the reply, saying what was dropped
+func failVerification(userID string) string {
+	var dropped []string
+	for _, sub := range SubManager.FailVerification(userID) {
+		dropped = append(dropped, fmt.Sprintf("**%s**", Games.DisplayName(sub.Game)))
+	}
+	topic, err := Profiles.FailVerification(userID)
+	if err != nil {
+		log.Printf("Error saving failed verification: %v", err)
+	}
+	if topic != "" {
+		dropped = append(dropped, fmt.Sprintf("NTFY topic `%s`", topic))
+	}
+
+	response := "❌ Error: nothing is waiting for that code"
+	if len(dropped) > 0 {
+		response += fmt.Sprintf("\nToo many wrong codes, dropped %s. Set it up again to get a new code.", strings.Join(dropped, ", "))
+	}
+	return response
+}
+
+// needsVerification reports whether a subscription's NTFY topic has to be
+// confirmed before it gets notifications
+func needsVerification(sub data.GameSubscription) bool {
+	return sub.DeliveryMethod() == data.DeliveryNTFY && !isTopicVerified(sub.UserID, sub.NTFYTopic)
+}
+
+// isTopicVerified reports whether the user already proved they own an NTFY
+// topic, through a subscription or their profile
+func isTopicVerified(userID, topic string) bool {
+	profile := Profiles.Get(userID)
+	if profile.DeliveryMethod() == data.DeliveryNTFY && profile.NTFYTopic == topic {
+		return true
+	}
+	return SubManager.IsTopicVerified(userID, topic)
+}
+
+// sendVerification pushes the verification code to a pending subscription's
+// topic and tells the user what to do next
+func sendVerification(s *discordgo.Session, i *discordgo.InteractionCreate, sub data.GameSubscription) {
+	// Pushing the code can take a moment, so acknowledge first
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	err := pushVerificationCode(sub, sub.VerifyCode)
+
+	var content string
+	if err != nil {
+		SubManager.Unsubscribe(sub.GuildID, sub.UserID, sub.Game)
+		content = fmt.Sprintf("❌ Error: couldn't send a verification code to `%s`: %s", sub.NTFYTopic, err.Error())
+	} else {
+		content = fmt.Sprintf("📨 We pushed a verification code to NTFY topic `%s`.\n"+
+			"Run `/verify <code>` within %d minutes to finish subscribing.", sub.NTFYTopic, int(verificationTTL.Minutes()))
+	}
+
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	})
+}
+
+// allowVerification checks and records that a user can be pushed a
+// verification code now
+func allowVerification(userID string, now time.Time) error {
+	lastVerificationMutex.Lock()
+	defer lastVerificationMutex.Unlock()
+
+	for id, at := range lastVerification {
+		if now.Sub(at) >= verificationCooldown {
+			delete(lastVerification, id)
+		}
+	}
+	if at, exists := lastVerification[userID]; exists {
+		return fmt.Errorf("a verification code was sent to you recently, try again <t:%d:R>", at.Add(verificationCooldown).Unix())
+	}
+	lastVerification[userID] = now
+	return nil
+}
+
+// forgetVerifications forgets when a user was last sent a verification code
+func forgetVerifications(userID string) {
+	lastVerificationMutex.Lock()
+	defer lastVerificationMutex.Unlock()
+	delete(lastVerification, userID)
+}
+
+// pushVerificationCode sends a verification code to an NTFY topic
+func pushVerificationCode(target data.GameSubscription, code string) error {
+	_, err := Notifier.Send(target, notify.Message{
+		Title: "🔐 Verify your NTFY topic",
+		Body:  fmt.Sprintf("Your verification code is %s\nRun /verify %s in Discord to start getting LFG notifications.", code, code),
+		Tags:  []string{"key"},
+	})
+	return err
+}
+
+// newVerifyCode returns a random six digit code
+func newVerifyCode() string {
+	n, _ := rand.Int(rand.Reader, big.NewInt(1000000))
+	return fmt.Sprintf("%06d", n.Int64())
+}
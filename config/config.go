@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -10,9 +12,19 @@ import (
 // Config holds all configuration for the bot
 type Config struct {
 	Token                    string
-	GuildID                  string
+	GuildID                  string // Commands are registered to this guild only, or globally if empty
+	LegacyGuildID            string // Guild that subscriptions from before multi-server support belong to
 	LFGChannelID             string
 	LFGAnnouncementChannelID string
+	NTFYBaseURL              string
+	NotifyMaxAttempts        int
+	NotifyCooldown           time.Duration
+	InitiatorCooldown        time.Duration
+	StorageBackend           string
+	SQLitePath               string
+	CallbackListenAddr       string
+	CallbackPublicURL        string
+	PresenceIntent           bool // Whether to request the privileged presences intent for the DND check
 }
 
 // Load loads configuration from environment variables
@@ -35,6 +47,14 @@ func Load() *Config {
 		log.Println("Warning: No DISCORD_GUILD_ID provided. Commands will be registered globally (takes up to 1 hour to appear).")
 	}
 
+	// Get the guild that subscriptions made before the bot served several
+	// servers belong to. It defaults to DISCORD_GUILD_ID, set it on its own to
+	// register commands globally and still keep the old subscriptions.
+	legacyGuildID := os.Getenv("LEGACY_GUILD_ID")
+	if legacyGuildID == "" {
+		legacyGuildID = guildID
+	}
+
 	// Get the LFG channel ID from environment variable
 	lfgChannelID := os.Getenv("DISCORD_LFG_CHANNEL_ID")
 	if lfgChannelID == "" {
@@ -47,10 +67,102 @@ func Load() *Config {
 		log.Println("Warning: No DISCORD_LFG_ANNOUNCEMENT_CHANNEL_ID provided. Will try to find a suitable channel automatically.")
 	}
 
+	// Get the NTFY server URL from environment variable
+	ntfyBaseURL := os.Getenv("NTFY_BASE_URL")
+	if ntfyBaseURL == "" {
+		ntfyBaseURL = "https://ntfy.sh"
+	}
+
+	// Get how many times a notification is retried before it is dead-lettered
+	notifyMaxAttempts := getInt("NOTIFY_MAX_ATTEMPTS", 6)
+
+	// Get how long to wait before notifying a subscriber about the same person and game again
+	notifyCooldown := getDuration("NOTIFY_COOLDOWN", 30*time.Minute)
+
+	// Get how long after one LFG join the same person can trigger notifications again
+	initiatorCooldown := getDuration("NOTIFY_INITIATOR_COOLDOWN", 5*time.Minute)
+
+	// Get the notification callback server settings from environment variables
+	callbackListenAddr := os.Getenv("CALLBACK_LISTEN_ADDR")
+	callbackPublicURL := os.Getenv("CALLBACK_PUBLIC_URL")
+	if callbackListenAddr != "" && callbackPublicURL == "" {
+		log.Println("Warning: CALLBACK_LISTEN_ADDR is set without CALLBACK_PUBLIC_URL. Notification buttons will be disabled.")
+		callbackListenAddr = ""
+	}
+
+	// Presences are privileged, so the DND check is only used when it was enabled in the developer portal
+	presenceIntent := getBool("ENABLE_PRESENCE_INTENT", false)
+
+	// Get where subscriptions are stored from environment variables
+	storageBackend := os.Getenv("STORAGE_BACKEND")
+	switch storageBackend {
+	case "":
+		storageBackend = "json"
+	case "json", "sqlite":
+	default:
+		log.Fatalf("Unknown STORAGE_BACKEND %q. Use \"json\" or \"sqlite\".", storageBackend)
+	}
+	sqlitePath := os.Getenv("SQLITE_PATH")
+	if sqlitePath == "" {
+		sqlitePath = "subscriptions.db"
+	}
+
 	return &Config{
 		Token:                    token,
 		GuildID:                  guildID,
+		LegacyGuildID:            legacyGuildID,
 		LFGChannelID:             lfgChannelID,
 		LFGAnnouncementChannelID: lfgAnnouncementChannelID,
+		NTFYBaseURL:              ntfyBaseURL,
+		NotifyMaxAttempts:        notifyMaxAttempts,
+		NotifyCooldown:           notifyCooldown,
+		InitiatorCooldown:        initiatorCooldown,
+		StorageBackend:           storageBackend,
+		SQLitePath:               sqlitePath,
+		CallbackListenAddr:       callbackListenAddr,
+		CallbackPublicURL:        callbackPublicURL,
+		PresenceIntent:           presenceIntent,
+	}
+}
+
+// getInt reads a positive integer from an environment variable
+func getInt(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 1 {
+		log.Printf("Warning: Invalid %s %q, using %d.", name, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// getBool reads true or false from an environment variable
+func getBool(name string, fallback bool) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: Invalid %s %q, using %t.", name, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// getDuration reads a duration like "30m" from an environment variable
+func getDuration(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		log.Printf("Warning: Invalid %s %q, using %s.", name, value, fallback)
+		return fallback
 	}
+	return parsed
 }
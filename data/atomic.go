@@ -0,0 +1,67 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WriteFileAtomic writes data to a temporary file next to filePath and renames
+// it into place, so a crash mid-write never leaves a truncated file behind
+func WriteFileAtomic(filePath string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(filePath)
+	tmp, err := os.CreateTemp(dir, filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	// Clean up the temporary file if anything goes wrong before the rename
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return err
+	}
+
+	// Sync the directory so the rename itself survives a crash
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// quarantineFile moves an unreadable file aside so it isn't overwritten by
+// the next save, and returns where it was moved to
+func quarantineFile(filePath string) (string, error) {
+	quarantined := fmt.Sprintf("%s.corrupt-%s", filePath, time.Now().UTC().Format("20060102-150405"))
+	if err := os.Rename(filePath, quarantined); err != nil {
+		return "", err
+	}
+	return quarantined, nil
+}
+
+// unreadable quarantines a file that couldn't be decoded and returns an error
+// saying where it went
+func unreadable(filePath string, err error) error {
+	quarantined, qerr := quarantineFile(filePath)
+	if qerr != nil {
+		return fmt.Errorf("%s is unreadable (%v) and couldn't be moved aside: %v", filePath, err, qerr)
+	}
+	return fmt.Errorf("%s is unreadable and was moved to %s: %v", filePath, quarantined, err)
+}
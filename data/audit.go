@@ -0,0 +1,130 @@
+package data
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Actions recorded in the audit log
+const (
+	AuditErasure = "erasure" // A user had the bot forget everything about them
+)
+
+// AuditEntry records something done to personal data
+type AuditEntry struct {
+	Time    time.Time      `json:"time"`
+	Action  string         `json:"action"`
+	Subject string         `json:"subject"`           // Keyed hash of the user ID, see AuditLog.HashUserID
+	Removed map[string]int `json:"removed,omitempty"` // Store name -> how many records were removed or changed
+	Error   string         `json:"error,omitempty"`   // Set if part of the action failed
+}
+
+// AuditLog is an append-only log of changes to personal data
+type AuditLog struct {
+	entries  []AuditEntry
+	filePath string
+	key      []byte // Secret the user IDs are hashed with, kept outside the log
+	mutex    sync.Mutex
+}
+
+// auditKeySize is how many random bytes a new audit key has
+const auditKeySize = 32
+
+// NewAuditLog creates a new audit log. User IDs are hashed with the secret in
+// keyPath, which is generated the first time. It fails if the stored log or
+// key can't be loaded, rather than starting empty and overwriting them.
+func NewAuditLog(filePath, keyPath string) (*AuditLog, error) {
+	al := &AuditLog{
+		entries:  make([]AuditEntry, 0),
+		filePath: filePath,
+	}
+	if err := al.loadFromFile(); err != nil {
+		return nil, fmt.Errorf("error loading audit log: %v", err)
+	}
+	key, err := loadAuditKey(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading audit key: %v", err)
+	}
+	al.key = key
+	return al, nil
+}
+
+// HashUserID identifies a user in the audit log without keeping their ID. The
+// result is pseudonymous, not anonymous: the same user always gets the same
+// hash, so whoever holds the key can match a user against the log, but the
+// log alone can't be reversed by hashing every possible ID.
+func (al *AuditLog) HashUserID(userID string) string {
+	mac := hmac.New(sha256.New, al.key)
+	mac.Write([]byte(userID))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// loadAuditKey reads the secret used to hash user IDs, generating it if it
+// doesn't exist yet. Losing it means old entries can't be matched any more.
+func loadAuditKey(keyPath string) ([]byte, error) {
+	contents, err := os.ReadFile(keyPath)
+	if err == nil {
+		key, err := hex.DecodeString(string(contents))
+		if err != nil || len(key) == 0 {
+			return nil, fmt.Errorf("%s isn't a valid key", keyPath)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key := make([]byte, auditKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := WriteFileAtomic(keyPath, []byte(hex.EncodeToString(key)), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Record adds an entry to the audit log
+func (al *AuditLog) Record(entry AuditEntry) error {
+	al.mutex.Lock()
+	defer al.mutex.Unlock()
+
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	al.entries = append(al.entries, entry)
+	return al.saveToFile()
+}
+
+// saveToFile saves the audit log to JSON file
+func (al *AuditLog) saveToFile() error {
+	data, err := json.MarshalIndent(al.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(al.filePath, data, 0600)
+}
+
+// loadFromFile loads the audit log from JSON file
+func (al *AuditLog) loadFromFile() error {
+	data, err := os.ReadFile(al.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// File doesn't exist yet, that's okay
+			return nil
+		}
+		return err
+	}
+
+	if err := json.Unmarshal(data, &al.entries); err != nil {
+		return unreadable(al.filePath, err)
+	}
+	return nil
+}
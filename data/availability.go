@@ -0,0 +1,145 @@
+package data
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AvailabilityWindow is a recurring time a subscriber wants to hear about a
+// game. No days means every day. A window that wraps past midnight belongs to
+// the day it starts on, so Friday 22:00-02:00 runs into Saturday morning.
+type AvailabilityWindow struct {
+	Days []time.Weekday `json:"days,omitempty"`
+	TimeWindow
+}
+
+var (
+	weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	weekends = []time.Weekday{time.Saturday, time.Sunday}
+)
+
+// dayNames maps the names a day can be given as to the day
+var dayNames = map[string]time.Weekday{
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+	"sun": time.Sunday, "sunday": time.Sunday,
+}
+
+// ParseWeekdays parses days like "mon-fri", "sat,sun", "weekdays" or
+// "weekends". An empty string or "daily" means every day and returns nil.
+func ParseWeekdays(input string) ([]time.Weekday, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	switch input {
+	case "", "daily", "every day", "everyday", "all":
+		return nil, nil
+	case "weekdays", "weeknights":
+		return slices.Clone(weekdays), nil
+	case "weekends":
+		return slices.Clone(weekends), nil
+	}
+
+	var days []time.Weekday
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		first, last, isRange := strings.Cut(part, "-")
+		from, exists := dayNames[strings.TrimSpace(first)]
+		if !exists {
+			return nil, fmt.Errorf("%q is not a day, use mon, tue, wed, thu, fri, sat or sun", first)
+		}
+		to := from
+		if isRange {
+			if to, exists = dayNames[strings.TrimSpace(last)]; !exists {
+				return nil, fmt.Errorf("%q is not a day, use mon, tue, wed, thu, fri, sat or sun", last)
+			}
+		}
+		// Ranges can wrap around the week, like fri-mon
+		for day := from; ; day = (day + 1) % 7 {
+			if !slices.Contains(days, day) {
+				days = append(days, day)
+			}
+			if day == to {
+				break
+			}
+		}
+	}
+
+	if len(days) == 7 {
+		return nil, nil
+	}
+	// Monday first, the way people read a week
+	slices.SortFunc(days, func(a, b time.Weekday) int {
+		return (int(a)+6)%7 - (int(b)+6)%7
+	})
+	return days, nil
+}
+
+// FormatWeekdays formats days the way ParseWeekdays reads them
+func FormatWeekdays(days []time.Weekday) string {
+	switch {
+	case len(days) == 0:
+		return "daily"
+	case slices.Equal(days, weekdays):
+		return "weekdays"
+	case slices.Equal(days, weekends):
+		return "weekends"
+	}
+
+	names := make([]string, 0, len(days))
+	for _, day := range days {
+		names = append(names, day.String()[:3])
+	}
+	return strings.Join(names, ",")
+}
+
+// Contains reports whether a local time falls inside the window
+func (w AvailabilityWindow) Contains(local time.Time) bool {
+	minute := local.Hour()*60 + local.Minute()
+	if !w.TimeWindow.Contains(minute) {
+		return false
+	}
+	if len(w.Days) == 0 {
+		return true
+	}
+
+	// The early morning part of a window that wraps belongs to the day before
+	day := local.Weekday()
+	if w.End < w.Start && minute < w.End {
+		day = (day + 6) % 7
+	}
+	return slices.Contains(w.Days, day)
+}
+
+// String formats the window like "weekdays 19:00-23:00"
+func (w AvailabilityWindow) String() string {
+	return fmt.Sprintf("%s %s", FormatWeekdays(w.Days), w.TimeWindow)
+}
+
+// ParseAvailabilityWindow parses a window formatted by String, like
+// "weekdays 19:00-23:00"
+func ParseAvailabilityWindow(input string) (AvailabilityWindow, error) {
+	input = strings.TrimSpace(input)
+	days, times := "", input
+	if i := strings.LastIndex(input, " "); i >= 0 {
+		days, times = input[:i], input[i+1:]
+	}
+
+	start, end, found := strings.Cut(times, "-")
+	if !found {
+		return AvailabilityWindow{}, fmt.Errorf("%q is not a window, use something like `weekdays 19:00-23:00`", input)
+	}
+	window, err := ParseTimeWindow(start, end)
+	if err != nil {
+		return AvailabilityWindow{}, err
+	}
+	weekdays, err := ParseWeekdays(days)
+	if err != nil {
+		return AvailabilityWindow{}, err
+	}
+	return AvailabilityWindow{Days: weekdays, TimeWindow: window}, nil
+}
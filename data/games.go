@@ -0,0 +1,296 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Game is a game people can subscribe to
+type Game struct {
+	ID        string   `json:"id"`                   // Slug like "rocket-league"
+	Name      string   `json:"name"`                 // Display name like "Rocket League"
+	Aliases   []string `json:"aliases,omitempty"`    // Other IDs that mean this game, like "csgo" for "cs2"
+	Emoji     string   `json:"emoji,omitempty"`      // NTFY emoji short code shown on notifications
+	PartySize int      `json:"party_size,omitempty"` // How many people make a full party, 0 if it doesn't matter
+}
+
+// defaultEmoji is shown on notifications for games without their own emoji
+const defaultEmoji = "video_game"
+
+// MaxGameNameLength is the longest a game's ID or name can be, Discord's limit
+// for a command choice
+const MaxGameNameLength = 100
+
+// DefaultGames seed the catalog the first time the bot runs
+var DefaultGames = []Game{
+	{ID: "valorant", Name: "Valorant", Emoji: "dart", PartySize: 5},
+	{ID: "cs2", Name: "Counter-Strike 2", Aliases: []string{"csgo"}, Emoji: "bomb", PartySize: 5},
+	{ID: "overwatch", Name: "Overwatch", Emoji: "shield", PartySize: 5},
+	{ID: "apex", Name: "Apex Legends", Emoji: "trophy", PartySize: 3},
+	{ID: "fortnite", Name: "Fortnite", Emoji: "parachute", PartySize: 4},
+	{ID: "minecraft", Name: "Minecraft", Emoji: "pick"},
+	{ID: "rocket-league", Name: "Rocket League", Emoji: "soccer", PartySize: 3},
+	{ID: "cod", Name: "Call of Duty", Emoji: "military_helmet", PartySize: 6},
+	{ID: "warzone", Name: "Warzone", Emoji: "military_helmet", PartySize: 4},
+	{ID: "dota2", Name: "Dota 2", Emoji: "crossed_swords", PartySize: 5},
+	{ID: "lol", Name: "League of Legends", Aliases: []string{"league"}, Emoji: "crossed_swords", PartySize: 5},
+	{ID: "among-us", Name: "Among Us", Emoji: "rocket", PartySize: 10},
+	{ID: "fall-guys", Name: "Fall Guys", Emoji: "crown", PartySize: 4},
+	{ID: "gta", Name: "GTA Online", Emoji: "red_car"},
+	{ID: "rust", Name: "Rust", Emoji: "hammer"},
+	{ID: "destiny2", Name: "Destiny 2", Emoji: "milky_way", PartySize: 3},
+	{ID: "wow", Name: "World of Warcraft", Emoji: "dragon", PartySize: 5},
+}
+
+// NormalizeGameID turns user input like " Rocket League" into a game ID like "rocket-league"
+func NormalizeGameID(input string) string {
+	return strings.Join(strings.Fields(strings.ToLower(input)), "-")
+}
+
+// GameCatalog manages the games people can subscribe to
+type GameCatalog struct {
+	games    map[string]Game   // Game ID -> game
+	aliases  map[string]string // Alias -> game ID
+	filePath string
+	mutex    sync.RWMutex
+}
+
+// NewGameCatalog creates a new game catalog, seeded with DefaultGames if it
+// doesn't exist yet. It fails if the stored catalog can't be loaded, rather
+// than starting over with the defaults and overwriting it.
+func NewGameCatalog(filePath string) (*GameCatalog, error) {
+	gc := &GameCatalog{
+		games:    make(map[string]Game),
+		aliases:  make(map[string]string),
+		filePath: filePath,
+	}
+	err := gc.loadFromFile()
+	if os.IsNotExist(err) {
+		for _, game := range DefaultGames {
+			gc.put(game)
+		}
+		if err := gc.saveToFile(); err != nil {
+			return nil, fmt.Errorf("error saving game catalog: %v", err)
+		}
+		return gc, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading game catalog: %v", err)
+	}
+	return gc, nil
+}
+
+// All returns every game, sorted by name
+func (gc *GameCatalog) All() []Game {
+	gc.mutex.RLock()
+	defer gc.mutex.RUnlock()
+
+	games := make([]Game, 0, len(gc.games))
+	for _, game := range gc.games {
+		games = append(games, game)
+	}
+	sort.Slice(games, func(i, j int) bool {
+		return strings.ToLower(games[i].Name) < strings.ToLower(games[j].Name)
+	})
+	return games
+}
+
+// Resolve finds a game by ID, alias or name
+func (gc *GameCatalog) Resolve(input string) (Game, bool) {
+	gc.mutex.RLock()
+	defer gc.mutex.RUnlock()
+
+	id := NormalizeGameID(input)
+	if game, exists := gc.games[id]; exists {
+		return game, true
+	}
+	if game, exists := gc.games[gc.aliases[id]]; exists {
+		return game, true
+	}
+	for _, game := range gc.games {
+		if NormalizeGameID(game.Name) == id {
+			return game, true
+		}
+	}
+	return Game{}, false
+}
+
+// DisplayName returns a game's name. Games missing from the catalog, or a nil
+// catalog, turn the ID into a name like "Rocket League".
+func (gc *GameCatalog) DisplayName(id string) string {
+	if gc != nil {
+		gc.mutex.RLock()
+		game, exists := gc.games[id]
+		gc.mutex.RUnlock()
+		if exists {
+			return game.Name
+		}
+	}
+
+	words := strings.Fields(strings.ReplaceAll(id, "-", " "))
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// Emoji returns the NTFY emoji short code for a game. A nil catalog uses the default.
+func (gc *GameCatalog) Emoji(id string) string {
+	if gc == nil {
+		return defaultEmoji
+	}
+
+	gc.mutex.RLock()
+	defer gc.mutex.RUnlock()
+
+	if game, exists := gc.games[id]; exists && game.Emoji != "" {
+		return game.Emoji
+	}
+	return defaultEmoji
+}
+
+// Add adds a new game to the catalog
+func (gc *GameCatalog) Add(game Game) error {
+	gc.mutex.Lock()
+	defer gc.mutex.Unlock()
+
+	if err := gc.validate(&game, ""); err != nil {
+		return err
+	}
+	if _, exists := gc.games[game.ID]; exists {
+		return fmt.Errorf("%s is already in the catalog", game.ID)
+	}
+
+	gc.put(game)
+	return gc.saveToFile()
+}
+
+// Edit applies a change to a game in the catalog and saves it
+func (gc *GameCatalog) Edit(id string, change func(game *Game) error) (Game, error) {
+	gc.mutex.Lock()
+	defer gc.mutex.Unlock()
+
+	game, exists := gc.games[id]
+	if !exists {
+		return Game{}, fmt.Errorf("%s isn't in the catalog", id)
+	}
+	if err := change(&game); err != nil {
+		return Game{}, err
+	}
+	game.ID = id
+	if err := gc.validate(&game, id); err != nil {
+		return Game{}, err
+	}
+
+	gc.remove(id)
+	gc.put(game)
+	return game, gc.saveToFile()
+}
+
+// Remove removes a game from the catalog
+func (gc *GameCatalog) Remove(id string) error {
+	gc.mutex.Lock()
+	defer gc.mutex.Unlock()
+
+	if _, exists := gc.games[id]; !exists {
+		return fmt.Errorf("%s isn't in the catalog", id)
+	}
+
+	gc.remove(id)
+	return gc.saveToFile()
+}
+
+// validate normalizes a game and checks its ID and aliases don't clash with
+// other games. Must hold the write lock.
+func (gc *GameCatalog) validate(game *Game, editing string) error {
+	game.ID = NormalizeGameID(game.ID)
+	game.Name = strings.TrimSpace(game.Name)
+	game.Emoji = strings.Trim(strings.TrimSpace(game.Emoji), ":")
+	if game.ID == "" {
+		return fmt.Errorf("the game needs an ID")
+	}
+	if game.Name == "" {
+		return fmt.Errorf("the game needs a name")
+	}
+	if utf8.RuneCountInString(game.ID) > MaxGameNameLength || utf8.RuneCountInString(game.Name) > MaxGameNameLength {
+		return fmt.Errorf("the game's ID and name can be at most %d characters", MaxGameNameLength)
+	}
+	if game.PartySize < 0 {
+		return fmt.Errorf("party size can't be negative")
+	}
+	if owner, exists := gc.aliases[game.ID]; exists && owner != editing {
+		return fmt.Errorf("%s is already an alias of %s", game.ID, owner)
+	}
+
+	aliases := make([]string, 0, len(game.Aliases))
+	for _, alias := range game.Aliases {
+		alias = NormalizeGameID(alias)
+		if alias == "" || alias == game.ID {
+			continue
+		}
+		if _, exists := gc.games[alias]; exists {
+			return fmt.Errorf("%s is already a game", alias)
+		}
+		if owner, exists := gc.aliases[alias]; exists && owner != editing {
+			return fmt.Errorf("%s is already an alias of %s", alias, owner)
+		}
+		aliases = append(aliases, alias)
+	}
+	game.Aliases = aliases
+	return nil
+}
+
+// put adds a game and its aliases. Must hold the write lock.
+func (gc *GameCatalog) put(game Game) {
+	gc.games[game.ID] = game
+	for _, alias := range game.Aliases {
+		gc.aliases[alias] = game.ID
+	}
+}
+
+// remove removes a game and its aliases. Must hold the write lock.
+func (gc *GameCatalog) remove(id string) {
+	for _, alias := range gc.games[id].Aliases {
+		delete(gc.aliases, alias)
+	}
+	delete(gc.games, id)
+}
+
+// saveToFile saves the catalog to JSON file
+func (gc *GameCatalog) saveToFile() error {
+	games := make([]Game, 0, len(gc.games))
+	for _, game := range gc.games {
+		games = append(games, game)
+	}
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].ID < games[j].ID
+	})
+
+	data, err := json.MarshalIndent(games, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(gc.filePath, data, 0644)
+}
+
+// loadFromFile loads the catalog from JSON file
+func (gc *GameCatalog) loadFromFile() error {
+	data, err := os.ReadFile(gc.filePath)
+	if err != nil {
+		return err
+	}
+
+	var games []Game
+	if err := json.Unmarshal(data, &games); err != nil {
+		return unreadable(gc.filePath, err)
+	}
+	for _, game := range games {
+		gc.put(game)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// GuildSettings is a guild's LFG configuration
+type GuildSettings struct {
+	GuildID               string `json:"guild_id"`
+	LFGChannelID          string `json:"lfg_channel_id,omitempty"`          // Voice channel people join to look for a game
+	AnnouncementChannelID string `json:"announcement_channel_id,omitempty"` // Text channel LFG announcements go to
+}
+
+// GuildManager manages per-guild LFG settings
+type GuildManager struct {
+	guilds   map[string]GuildSettings
+	filePath string
+	mutex    sync.RWMutex
+}
+
+// NewGuildManager creates a new guild settings manager. It fails if the
+// stored settings can't be loaded, rather than starting empty and
+// overwriting them.
+func NewGuildManager(filePath string) (*GuildManager, error) {
+	gm := &GuildManager{
+		guilds:   make(map[string]GuildSettings),
+		filePath: filePath,
+	}
+	if err := gm.loadFromFile(); err != nil {
+		return nil, fmt.Errorf("error loading guild settings: %v", err)
+	}
+	return gm, nil
+}
+
+// Get returns a guild's settings, or empty settings if it has none
+func (gm *GuildManager) Get(guildID string) GuildSettings {
+	gm.mutex.RLock()
+	defer gm.mutex.RUnlock()
+
+	settings, exists := gm.guilds[guildID]
+	if !exists {
+		return GuildSettings{GuildID: guildID}
+	}
+	return settings
+}
+
+// Update applies a change to a guild's settings and saves it
+func (gm *GuildManager) Update(guildID string, change func(settings *GuildSettings) error) error {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	settings, exists := gm.guilds[guildID]
+	if !exists {
+		settings = GuildSettings{GuildID: guildID}
+	}
+	if err := change(&settings); err != nil {
+		return err
+	}
+	gm.guilds[guildID] = settings
+
+	return gm.saveToFile()
+}
+
+// saveToFile saves guild settings to JSON file
+func (gm *GuildManager) saveToFile() error {
+	guilds := make([]GuildSettings, 0, len(gm.guilds))
+	for _, settings := range gm.guilds {
+		guilds = append(guilds, settings)
+	}
+	sort.Slice(guilds, func(i, j int) bool {
+		return guilds[i].GuildID < guilds[j].GuildID
+	})
+
+	data, err := json.MarshalIndent(guilds, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(gm.filePath, data, 0644)
+}
+
+// loadFromFile loads guild settings from JSON file
+func (gm *GuildManager) loadFromFile() error {
+	data, err := os.ReadFile(gm.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// File doesn't exist yet, that's okay
+			return nil
+		}
+		return err
+	}
+
+	var guilds []GuildSettings
+	if err := json.Unmarshal(data, &guilds); err != nil {
+		return unreadable(gm.filePath, err)
+	}
+	for _, settings := range guilds {
+		gm.guilds[settings.GuildID] = settings
+	}
+	return nil
+}
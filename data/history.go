@@ -0,0 +1,166 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Outcomes of a notification attempt
+const (
+	OutcomeSent       = "sent"
+	OutcomeRetrying   = "retrying"
+	OutcomeFailed     = "failed"     // Gave up after the last retry
+	OutcomeHeld       = "held"       // Saved for a digest
+	OutcomeSuppressed = "suppressed" // Deliberately not sent, see Reason
+)
+
+// HistoryEntry records a single notification attempt
+type HistoryEntry struct {
+	Time          time.Time `json:"time"`
+	GuildID       string    `json:"guild_id,omitempty"`
+	UserID        string    `json:"user_id"`
+	Username      string    `json:"username"`
+	Game          string    `json:"game,omitempty"`
+	InitiatorID   string    `json:"initiator_id,omitempty"`
+	InitiatorName string    `json:"initiator_name,omitempty"`
+	Backend       string    `json:"backend"`
+	Outcome       string    `json:"outcome"`
+	Reason        string    `json:"reason,omitempty"`
+}
+
+// HistoryManager keeps a rolling log of notification attempts
+type HistoryManager struct {
+	entries    []HistoryEntry
+	filePath   string
+	maxEntries int
+	mutex      sync.RWMutex
+}
+
+// NewHistoryManager creates a history log that keeps the latest maxEntries
+// attempts. It fails if the stored history can't be loaded.
+func NewHistoryManager(filePath string, maxEntries int) (*HistoryManager, error) {
+	hm := &HistoryManager{
+		entries:    make([]HistoryEntry, 0),
+		filePath:   filePath,
+		maxEntries: maxEntries,
+	}
+	if err := hm.loadFromFile(); err != nil {
+		return nil, fmt.Errorf("error loading notification history: %v", err)
+	}
+	return hm, nil
+}
+
+// Record adds entries to the history, saving it once
+func (hm *HistoryManager) Record(entries ...HistoryEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	hm.mutex.Lock()
+	defer hm.mutex.Unlock()
+
+	now := time.Now()
+	for _, entry := range entries {
+		if entry.Time.IsZero() {
+			entry.Time = now
+		}
+		hm.entries = append(hm.entries, entry)
+	}
+	if len(hm.entries) > hm.maxEntries {
+		hm.entries = hm.entries[len(hm.entries)-hm.maxEntries:]
+	}
+
+	return hm.saveToFile()
+}
+
+// ForUser returns the notifications sent to a user, newest first
+func (hm *HistoryManager) ForUser(userID string) []HistoryEntry {
+	return hm.filter(func(entry HistoryEntry) bool {
+		return entry.UserID == userID
+	})
+}
+
+// ForGuild returns the notifications for LFG sessions in a guild, newest first
+func (hm *HistoryManager) ForGuild(guildID string) []HistoryEntry {
+	return hm.filter(func(entry HistoryEntry) bool {
+		return entry.GuildID == guildID
+	})
+}
+
+// Initiated returns the notifications about LFG sessions a user started, newest first
+func (hm *HistoryManager) Initiated(userID string) []HistoryEntry {
+	return hm.filter(func(entry HistoryEntry) bool {
+		return entry.InitiatorID == userID
+	})
+}
+
+// ForgetUser removes the notifications sent to a user and takes their name
+// off the ones about sessions they started. It returns how many entries changed.
+func (hm *HistoryManager) ForgetUser(userID string) (int, error) {
+	hm.mutex.Lock()
+	defer hm.mutex.Unlock()
+
+	changed := 0
+	kept := make([]HistoryEntry, 0, len(hm.entries))
+	for _, entry := range hm.entries {
+		if entry.UserID == userID {
+			changed++
+			continue
+		}
+		if entry.InitiatorID == userID {
+			entry.InitiatorID = ""
+			entry.InitiatorName = ""
+			changed++
+		}
+		kept = append(kept, entry)
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+
+	hm.entries = kept
+	return changed, hm.saveToFile()
+}
+
+// filter returns matching entries, newest first
+func (hm *HistoryManager) filter(match func(entry HistoryEntry) bool) []HistoryEntry {
+	hm.mutex.RLock()
+	defer hm.mutex.RUnlock()
+
+	var entries []HistoryEntry
+	for i := len(hm.entries) - 1; i >= 0; i-- {
+		if match(hm.entries[i]) {
+			entries = append(entries, hm.entries[i])
+		}
+	}
+	return entries
+}
+
+// saveToFile saves the history to JSON file
+func (hm *HistoryManager) saveToFile() error {
+	data, err := json.MarshalIndent(hm.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(hm.filePath, data, 0644)
+}
+
+// loadFromFile loads the history from JSON file
+func (hm *HistoryManager) loadFromFile() error {
+	data, err := os.ReadFile(hm.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// File doesn't exist yet, that's okay
+			return nil
+		}
+		return err
+	}
+
+	if err := json.Unmarshal(data, &hm.entries); err != nil {
+		return unreadable(hm.filePath, err)
+	}
+	return nil
+}
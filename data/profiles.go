@@ -0,0 +1,394 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// What happens to notifications that arrive during quiet hours
+const (
+	QuietDrop   = "drop"   // Throw them away
+	QuietDigest = "digest" // Deliver them together when quiet hours end
+)
+
+// TimeWindow is a daily range of time in minutes after midnight. End may be
+// before Start for windows that wrap past midnight, like 23:00-07:00.
+type TimeWindow struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// ParseTimeWindow parses a window from two "HH:MM" clock times
+func ParseTimeWindow(start, end string) (TimeWindow, error) {
+	startMinute, err := parseClock(start)
+	if err != nil {
+		return TimeWindow{}, err
+	}
+	endMinute, err := parseClock(end)
+	if err != nil {
+		return TimeWindow{}, err
+	}
+	if startMinute == endMinute {
+		return TimeWindow{}, fmt.Errorf("start and end can't be the same time")
+	}
+	return TimeWindow{Start: startMinute, End: endMinute}, nil
+}
+
+// parseClock parses "HH:MM" into minutes after midnight
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a valid time, use HH:MM (e.g. 23:00)", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains reports whether a minute of the day falls inside the window
+func (w TimeWindow) Contains(minute int) bool {
+	if w.Start < w.End {
+		return minute >= w.Start && minute < w.End
+	}
+	return minute >= w.Start || minute < w.End
+}
+
+// String formats the window as "HH:MM-HH:MM"
+func (w TimeWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
+
+// UserProfile holds a user's personal notification preferences
+type UserProfile struct {
+	UserID      string       `json:"user_id"`
+	TimeZone    string       `json:"time_zone,omitempty"` // IANA name like "Europe/Oslo"
+	QuietHours  []TimeWindow `json:"quiet_hours,omitempty"`
+	QuietMode   string       `json:"quiet_mode,omitempty"` // QuietDrop or QuietDigest
+	SkipWhenDND bool         `json:"skip_when_dnd,omitempty"`
+
+	// Delivery settings shared by every subscription that doesn't set its own
+	Delivery   string `json:"delivery,omitempty"` // Defaults to Discord DM
+	NTFYTopic  string `json:"ntfy_topic,omitempty"`
+	WebhookURL string `json:"webhook_url,omitempty"`
+	Priority   int    `json:"priority,omitempty"`
+
+	// A new NTFY topic waiting for /verify before it replaces NTFYTopic
+	PendingTopic   string    `json:"pending_topic,omitempty"`
+	VerifyCode     string    `json:"verify_code,omitempty"`
+	VerifyExpires  time.Time `json:"verify_expires,omitzero"`
+	VerifyAttempts int       `json:"verify_attempts,omitempty"` // Wrong codes tried so far
+}
+
+// DeliveryMethod returns how the user wants to be notified by default
+func (p UserProfile) DeliveryMethod() string {
+	if p.Delivery == "" {
+		return DeliveryDiscord
+	}
+	return p.Delivery
+}
+
+// Resolve fills in the profile's delivery settings for a subscription that
+// doesn't have its own
+func (p UserProfile) Resolve(sub GameSubscription) GameSubscription {
+	if sub.UsesProfile() {
+		sub.Delivery = p.DeliveryMethod()
+		sub.NTFYTopic = p.NTFYTopic
+		sub.WebhookURL = p.WebhookURL
+	}
+	if sub.Priority == 0 {
+		sub.Priority = p.Priority
+	}
+	return sub
+}
+
+// Location returns the user's time zone, falling back to UTC
+func (p UserProfile) Location() *time.Location {
+	if p.TimeZone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// QuietHoursMode returns how notifications during quiet hours are handled
+func (p UserProfile) QuietHoursMode() string {
+	if p.QuietMode == "" {
+		return QuietDrop
+	}
+	return p.QuietMode
+}
+
+// QuietUntil reports whether t is inside the user's quiet hours and, if so,
+// when they end
+func (p UserProfile) QuietUntil(t time.Time) (time.Time, bool) {
+	local := t.In(p.Location())
+	quiet := false
+
+	// Step forward through back-to-back windows until we land outside all of them
+	for range len(p.QuietHours) + 1 {
+		minute := local.Hour()*60 + local.Minute()
+		window, inside := p.quietWindowAt(minute)
+		if !inside {
+			break
+		}
+		quiet = true
+
+		minutesLeft := window.End - minute
+		if minutesLeft <= 0 {
+			minutesLeft += 24 * 60
+		}
+		local = local.Truncate(time.Minute).Add(time.Duration(minutesLeft) * time.Minute)
+	}
+
+	return local, quiet
+}
+
+// quietWindowAt returns the quiet hours window containing a minute of the day
+func (p UserProfile) quietWindowAt(minute int) (TimeWindow, bool) {
+	for _, window := range p.QuietHours {
+		if window.Contains(minute) {
+			return window, true
+		}
+	}
+	return TimeWindow{}, false
+}
+
+// ProfileManager manages user profiles
+type ProfileManager struct {
+	profiles map[string]UserProfile
+	filePath string
+	mutex    sync.RWMutex
+}
+
+// NewProfileManager creates a new profile manager. It fails if the stored
+// profiles can't be loaded, rather than starting empty and overwriting them.
+func NewProfileManager(filePath string) (*ProfileManager, error) {
+	pm := &ProfileManager{
+		profiles: make(map[string]UserProfile),
+		filePath: filePath,
+	}
+	if err := pm.loadFromFile(); err != nil {
+		return nil, fmt.Errorf("error loading profiles: %v", err)
+	}
+	return pm, nil
+}
+
+// Get returns a user's profile, or an empty profile if they don't have one
+func (pm *ProfileManager) Get(userID string) UserProfile {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	profile, exists := pm.profiles[userID]
+	if !exists {
+		return UserProfile{UserID: userID}
+	}
+	return profile
+}
+
+// Update applies a change to a user's profile and saves it
+func (pm *ProfileManager) Update(userID string, change func(profile *UserProfile) error) error {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	profile, exists := pm.profiles[userID]
+	if !exists {
+		profile = UserProfile{UserID: userID}
+	}
+	if err := change(&profile); err != nil {
+		return err
+	}
+	pm.profiles[userID] = profile
+
+	return pm.saveToFile()
+}
+
+// Delete removes a user's profile
+func (pm *ProfileManager) Delete(userID string) (bool, error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if _, exists := pm.profiles[userID]; !exists {
+		return false, nil
+	}
+	delete(pm.profiles, userID)
+	return true, pm.saveToFile()
+}
+
+// VerifyTopic confirms a user's pending NTFY topic with the code that was
+// pushed to it, making it their delivery target, and returns the topic
+func (pm *ProfileManager) VerifyTopic(userID, code string, now time.Time) (string, error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	profile, exists := pm.profiles[userID]
+	if !exists || profile.PendingTopic == "" || profile.VerifyCode != code || now.After(profile.VerifyExpires) {
+		return "", fmt.Errorf("no pending topic matches that code")
+	}
+
+	topic := profile.PendingTopic
+	profile.Delivery = DeliveryNTFY
+	profile.NTFYTopic = topic
+	profile.WebhookURL = ""
+	profile.PendingTopic = ""
+	profile.VerifyCode = ""
+	profile.VerifyExpires = time.Time{}
+	profile.VerifyAttempts = 0
+	pm.profiles[userID] = profile
+
+	return topic, pm.saveToFile()
+}
+
+// FailVerification counts a wrong code against the user's pending NTFY topic.
+// Once it reaches MaxVerifyAttempts the topic is dropped and returned, so
+// codes can't be guessed.
+func (pm *ProfileManager) FailVerification(userID string) (string, error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	profile, exists := pm.profiles[userID]
+	if !exists || profile.PendingTopic == "" {
+		return "", nil
+	}
+
+	var dropped string
+	profile.VerifyAttempts++
+	if profile.VerifyAttempts >= MaxVerifyAttempts {
+		dropped = profile.PendingTopic
+		profile.PendingTopic = ""
+		profile.VerifyCode = ""
+		profile.VerifyExpires = time.Time{}
+		profile.VerifyAttempts = 0
+	}
+	pm.profiles[userID] = profile
+
+	return dropped, pm.saveToFile()
+}
+
+// FoldDeliveryIntoProfiles moves delivery settings that are repeated across a
+// user's subscriptions into their profile, so the subscriptions share one
+// setting. It returns how many subscriptions now use their owner's profile.
+// The subscriptions are saved with the manager's next flush.
+func FoldDeliveryIntoProfiles(sm *SubscriptionManager, pm *ProfileManager) (int, error) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	// Count how often each user repeats each delivery target
+	counts := make(map[string]map[string]int)
+	firstSeen := make(map[string]GameSubscription)
+	for _, sub := range sm.subscriptions {
+		if sub.Pending || sub.UsesProfile() {
+			continue
+		}
+		key := sub.DeliveryMethod() + ":" + sub.Target()
+		if counts[sub.UserID] == nil {
+			counts[sub.UserID] = make(map[string]int)
+		}
+		if counts[sub.UserID][key] == 0 {
+			firstSeen[sub.UserID+"|"+key] = sub
+		}
+		counts[sub.UserID][key]++
+	}
+
+	// Profiles without delivery settings take their owner's most used target.
+	// A target only one subscription uses is that subscription's own choice
+	// and stays with it.
+	folded := make(map[string]string) // user ID -> delivery key that now lives in the profile
+	profilesChanged := false
+	for userID, keys := range counts {
+		profile, exists := pm.profiles[userID]
+		if !exists {
+			profile = UserProfile{UserID: userID}
+		}
+		if profile.Delivery != "" {
+			key := profile.DeliveryMethod() + ":" + profile.Resolve(GameSubscription{UserID: userID}).Target()
+			if keys[key] >= 2 {
+				folded[userID] = key
+			}
+			continue
+		}
+
+		bestKey, bestCount := "", 0
+		for key, count := range keys {
+			if count > bestCount || (count == bestCount && key < bestKey) {
+				bestKey, bestCount = key, count
+			}
+		}
+		if bestCount < 2 {
+			continue
+		}
+		sub := firstSeen[userID+"|"+bestKey]
+		profile.Delivery = sub.DeliveryMethod()
+		profile.NTFYTopic = sub.NTFYTopic
+		profile.WebhookURL = sub.WebhookURL
+		pm.profiles[userID] = profile
+		folded[userID] = bestKey
+		profilesChanged = true
+	}
+
+	// Save profiles first so a failure never leaves subscriptions without a target
+	if profilesChanged {
+		if err := pm.saveToFile(); err != nil {
+			return 0, err
+		}
+	}
+
+	moved := 0
+	for _, sub := range sm.subscriptions {
+		if sub.Pending || sub.UsesProfile() {
+			continue
+		}
+		if folded[sub.UserID] == sub.DeliveryMethod()+":"+sub.Target() {
+			sub.Delivery = ""
+			sub.NTFYTopic = ""
+			sub.WebhookURL = ""
+			sm.put(sub)
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+// saveToFile saves profiles to JSON file
+func (pm *ProfileManager) saveToFile() error {
+	profiles := make([]UserProfile, 0, len(pm.profiles))
+	for _, profile := range pm.profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].UserID < profiles[j].UserID
+	})
+
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(pm.filePath, data, 0644)
+}
+
+// loadFromFile loads profiles from JSON file
+func (pm *ProfileManager) loadFromFile() error {
+	data, err := os.ReadFile(pm.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// File doesn't exist yet, that's okay
+			return nil
+		}
+		return err
+	}
+
+	var profiles []UserProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return unreadable(pm.filePath, err)
+	}
+	for _, profile := range profiles {
+		pm.profiles[profile.UserID] = profile
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package data
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	// Pure Go SQLite driver, no cgo needed
+	_ "modernc.org/sqlite"
+)
+
+// sqliteVersion is the current version of the SQLite schema. It's versioned
+// separately from the subscriptions file, the rows keep the file's fields as
+// JSON so only table changes need a new version.
+const sqliteVersion = 3
+
+// sqliteSchema creates the subscriptions table. Each row keeps the whole
+// subscription as JSON so new fields don't need a schema change.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS subscriptions (
+	id       INTEGER PRIMARY KEY,
+	guild_id TEXT NOT NULL DEFAULT '',
+	user_id  TEXT NOT NULL,
+	game     TEXT NOT NULL,
+	data     TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS subscriptions_key ON subscriptions (guild_id, user_id, game);
+CREATE INDEX IF NOT EXISTS subscriptions_guild_id ON subscriptions (guild_id);
+CREATE INDEX IF NOT EXISTS subscriptions_user_id ON subscriptions (user_id);
+CREATE INDEX IF NOT EXISTS subscriptions_game ON subscriptions (game);
+`
+
+// sqliteMigrations upgrade an existing database one schema version at a time.
+// sqliteMigrations[n] turns a version n database into a version n+1 one.
+var sqliteMigrations = map[int]string{
+	1: "ALTER TABLE subscriptions ADD COLUMN guild_id TEXT NOT NULL DEFAULT ''",
+	// Keep the newest row of any duplicates so each subscription can be upserted by its key
+	2: `DELETE FROM subscriptions WHERE id NOT IN (SELECT MAX(id) FROM subscriptions GROUP BY guild_id, user_id, game);
+CREATE UNIQUE INDEX IF NOT EXISTS subscriptions_key ON subscriptions (guild_id, user_id, game);`,
+}
+
+// SQLiteStore keeps subscriptions in an embedded SQLite database
+type SQLiteStore struct {
+	db    *sql.DB
+	saved map[subscriptionKey]string // What each row holds, so saves only write rows that changed
+}
+
+// NewSQLiteStore opens (and creates if needed) a SQLite database file
+func NewSQLiteStore(filePath string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening SQLite database: %v", err)
+	}
+	// SQLite only allows one writer at a time anyway
+	db.SetMaxOpenConns(1)
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error reading SQLite schema version: %v", err)
+	}
+	if version > sqliteVersion {
+		db.Close()
+		return nil, fmt.Errorf("%s is schema version %d but this bot only understands up to version %d", filePath, version, sqliteVersion)
+	}
+
+	// Databases from before the schema was versioned are version 0 too, but
+	// unlike a new one they already have the version 1 table
+	if version == 0 {
+		var tables int
+		err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'subscriptions'").Scan(&tables)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("error reading SQLite schema: %v", err)
+		}
+		if tables > 0 {
+			version = 1
+		}
+	}
+
+	// A new database (version 0) is created with the current schema
+	for ; version > 0 && version < sqliteVersion; version++ {
+		if _, err := db.Exec(sqliteMigrations[version]); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("error migrating SQLite schema from version %d: %v", version, err)
+		}
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating SQLite schema: %v", err)
+	}
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", sqliteVersion)); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error setting SQLite schema version: %v", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// Load loads every subscription from the database
+func (ss *SQLiteStore) Load() ([]GameSubscription, error) {
+	rows, err := ss.db.Query("SELECT data FROM subscriptions ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []GameSubscription
+	saved := make(map[subscriptionKey]string)
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+
+		var sub GameSubscription
+		if err := json.Unmarshal([]byte(raw), &sub); err != nil {
+			return nil, fmt.Errorf("error decoding subscription: %v", err)
+		}
+		subscriptions = append(subscriptions, sub)
+		saved[keyOf(sub)] = raw
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	ss.saved = saved
+	return subscriptions, nil
+}
+
+// Save makes the database match the given subscriptions in a single
+// transaction, only writing the rows that were added, changed or removed
+func (ss *SQLiteStore) Save(subscriptions []GameSubscription) error {
+	// Saving before loading has nothing to compare against
+	if ss.saved == nil {
+		if _, err := ss.Load(); err != nil {
+			return err
+		}
+	}
+
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	upsert, err := tx.Prepare(`INSERT INTO subscriptions (guild_id, user_id, game, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (guild_id, user_id, game) DO UPDATE SET data = excluded.data`)
+	if err != nil {
+		return err
+	}
+	defer upsert.Close()
+
+	saved := make(map[subscriptionKey]string, len(subscriptions))
+	for _, sub := range subscriptions {
+		raw, err := json.Marshal(sub)
+		if err != nil {
+			return err
+		}
+		key := keyOf(sub)
+		saved[key] = string(raw)
+		if ss.saved[key] == string(raw) {
+			continue
+		}
+		if _, err := upsert.Exec(sub.GuildID, sub.UserID, sub.Game, string(raw)); err != nil {
+			return err
+		}
+	}
+
+	remove, err := tx.Prepare("DELETE FROM subscriptions WHERE guild_id = ? AND user_id = ? AND game = ?")
+	if err != nil {
+		return err
+	}
+	defer remove.Close()
+
+	for key := range ss.saved {
+		if _, exists := saved[key]; exists {
+			continue
+		}
+		if _, err := remove.Exec(key.GuildID, key.UserID, key.Game); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	ss.saved = saved
+	return nil
+}
+
+// Close closes the database
+func (ss *SQLiteStore) Close() error {
+	return ss.db.Close()
+}
@@ -0,0 +1,141 @@
+package data
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSQLiteStoreSavesChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscriptions.db")
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	alice := GameSubscription{GuildID: "1", UserID: "alice", Game: "valorant"}
+	bob := GameSubscription{GuildID: "1", UserID: "bob", Game: "valorant"}
+	if err := store.Save([]GameSubscription{alice, bob}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Change one, remove one and add one
+	alice.Priority = 5
+	carol := GameSubscription{GuildID: "2", UserID: "carol", Game: "minecraft"}
+	if err := store.Save([]GameSubscription{alice, carol}); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	loaded, err := reopened.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []GameSubscription{alice, carol}; !reflect.DeepEqual(loaded, want) {
+		t.Errorf("loaded %+v, want %+v", loaded, want)
+	}
+}
+
+func TestSQLiteStoreMigratesDuplicates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscriptions.db")
+
+	// A version 2 database, which could hold the same subscription twice
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+CREATE TABLE subscriptions (
+	id       INTEGER PRIMARY KEY,
+	guild_id TEXT NOT NULL DEFAULT '',
+	user_id  TEXT NOT NULL,
+	game     TEXT NOT NULL,
+	data     TEXT NOT NULL
+);
+INSERT INTO subscriptions (guild_id, user_id, game, data) VALUES
+	('1', 'alice', 'valorant', '{"guild_id":"1","user_id":"alice","game":"valorant","priority":1}'),
+	('1', 'alice', 'valorant', '{"guild_id":"1","user_id":"alice","game":"valorant","priority":2}');
+PRAGMA user_version = 2;`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 || loaded[0].Priority != 2 {
+		t.Errorf("loaded %+v, want only the newest duplicate", loaded)
+	}
+
+	var version int
+	if err := store.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if version != sqliteVersion {
+		t.Errorf("got schema version %d, want %d", version, sqliteVersion)
+	}
+}
+
+func TestSQLiteStoreMigratesUnversioned(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscriptions.db")
+
+	// The first SQLite schema had no version and no guild_id column
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+CREATE TABLE subscriptions (
+	id      INTEGER PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	game    TEXT NOT NULL,
+	data    TEXT NOT NULL
+);
+CREATE INDEX subscriptions_user_id ON subscriptions (user_id);
+CREATE INDEX subscriptions_game ON subscriptions (game);
+INSERT INTO subscriptions (user_id, game, data) VALUES
+	('alice', 'valorant', '{"user_id":"alice","game":"valorant"}');`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 || loaded[0].UserID != "alice" || loaded[0].GuildID != "" {
+		t.Errorf("loaded %+v, want alice's subscription without a guild", loaded)
+	}
+
+	// Saving works against the migrated table, keyed on the new guild_id column
+	loaded[0].GuildID = "1"
+	if err := store.Save(loaded); err != nil {
+		t.Fatal(err)
+	}
+	var rows int
+	if err := store.db.QueryRow("SELECT COUNT(*) FROM subscriptions WHERE guild_id = '1'").Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 1 {
+		t.Errorf("got %d rows in guild 1, want 1", rows)
+	}
+}
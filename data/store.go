@@ -0,0 +1,167 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Store persists subscriptions for a SubscriptionManager
+type Store interface {
+	// Load returns every stored subscription
+	Load() ([]GameSubscription, error)
+	// Save replaces the stored subscriptions
+	Save(subscriptions []GameSubscription) error
+	// Close releases the store's resources
+	Close() error
+}
+
+// subscriptionsVersion is the current version of the subscriptions file
+const subscriptionsVersion = 2
+
+// subscriptionsFile is the versioned envelope the subscriptions are saved in
+type subscriptionsFile struct {
+	Version       int                `json:"version"`
+	Subscriptions []GameSubscription `json:"subscriptions"`
+}
+
+// subscriptionMigrations upgrade the raw file contents one version at a time.
+// subscriptionMigrations[n] turns a version n file into a version n+1 file.
+var subscriptionMigrations = []func(contents []byte) ([]byte, error){
+	// Version 0 was a bare array of subscriptions
+	func(contents []byte) ([]byte, error) {
+		var subscriptions []GameSubscription
+		if err := json.Unmarshal(contents, &subscriptions); err != nil {
+			return nil, err
+		}
+		return json.Marshal(subscriptionsFile{Version: 1, Subscriptions: subscriptions})
+	},
+	// Version 2 added guild IDs, existing subscriptions are assigned to the
+	// configured guild by SubscriptionManager.AssignGuild
+	func(contents []byte) ([]byte, error) {
+		var file subscriptionsFile
+		if err := json.Unmarshal(contents, &file); err != nil {
+			return nil, err
+		}
+		file.Version = 2
+		return json.Marshal(file)
+	},
+}
+
+// JSONStore keeps subscriptions in a versioned JSON file
+type JSONStore struct {
+	FilePath string
+}
+
+// NewJSONStore creates a store backed by a JSON file
+func NewJSONStore(filePath string) *JSONStore {
+	return &JSONStore{
+		FilePath: filePath,
+	}
+}
+
+// Load loads subscriptions from the JSON file, migrating older versions. A
+// file that can't be read is quarantined and an error is returned, so it
+// isn't overwritten with an empty list.
+func (js *JSONStore) Load() ([]GameSubscription, error) {
+	contents, err := os.ReadFile(js.FilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// File doesn't exist yet, that's okay
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	subscriptions, err := decodeSubscriptions(contents)
+	if err != nil {
+		return nil, unreadable(js.FilePath, err)
+	}
+	return subscriptions, nil
+}
+
+// decodeSubscriptions decodes a subscriptions file of any known version
+func decodeSubscriptions(contents []byte) ([]GameSubscription, error) {
+	version, err := fileVersion(contents)
+	if err != nil {
+		return nil, err
+	}
+	if version > subscriptionsVersion {
+		return nil, fmt.Errorf("file is version %d but this bot only understands up to version %d", version, subscriptionsVersion)
+	}
+
+	for ; version < subscriptionsVersion; version++ {
+		contents, err = subscriptionMigrations[version](contents)
+		if err != nil {
+			return nil, fmt.Errorf("error migrating from version %d: %v", version, err)
+		}
+	}
+
+	var file subscriptionsFile
+	if err := json.Unmarshal(contents, &file); err != nil {
+		return nil, err
+	}
+	return file.Subscriptions, nil
+}
+
+// fileVersion returns the version of a subscriptions file. Files from before
+// versioning are a bare array and count as version 0.
+func fileVersion(contents []byte) (int, error) {
+	trimmed := bytes.TrimSpace(contents)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return 0, nil
+	}
+
+	var header struct {
+		Version *int `json:"version"`
+	}
+	if err := json.Unmarshal(trimmed, &header); err != nil {
+		return 0, err
+	}
+	if header.Version == nil {
+		return 0, fmt.Errorf("file has no version")
+	}
+	return *header.Version, nil
+}
+
+// Save atomically saves subscriptions to the JSON file
+func (js *JSONStore) Save(subscriptions []GameSubscription) error {
+	if subscriptions == nil {
+		subscriptions = []GameSubscription{}
+	}
+	contents, err := json.MarshalIndent(subscriptionsFile{
+		Version:       subscriptionsVersion,
+		Subscriptions: subscriptions,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(js.FilePath, contents, 0644)
+}
+
+// Close does nothing, the file is only open while loading or saving
+func (js *JSONStore) Close() error {
+	return nil
+}
+
+// ImportJSON copies subscriptions from a JSON file into an empty store, so
+// switching backends doesn't lose anyone's subscriptions. It returns how many
+// subscriptions were imported.
+func ImportJSON(filePath string, store Store) (int, error) {
+	existing, err := store.Load()
+	if err != nil {
+		return 0, err
+	}
+	if len(existing) > 0 {
+		// Already migrated
+		return 0, nil
+	}
+
+	subscriptions, err := NewJSONStore(filePath).Load()
+	if err != nil || len(subscriptions) == 0 {
+		return 0, err
+	}
+
+	return len(subscriptions), store.Save(subscriptions)
+}
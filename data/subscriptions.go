@@ -1,141 +1,690 @@
 package data
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
+	"log"
+	"reflect"
+	"sort"
 	"sync"
+	"time"
+)
+
+// Delivery methods a subscription can use
+const (
+	DeliveryNTFY    = "ntfy"
+	DeliveryDiscord = "discord"
+	DeliveryWebhook = "webhook"
 )
 
 // GameSubscription represents a user's subscription to a game
 type GameSubscription struct {
-	UserID    string `json:"user_id"`
-	Username  string `json:"username"`
-	Game      string `json:"game"`
-	NTFYTopic string `json:"ntfy_topic"` // Their personal NTFY topic
+	GuildID    string `json:"guild_id"` // Server the subscription was made in
+	UserID     string `json:"user_id"`
+	Username   string `json:"username"`
+	Game       string `json:"game"`
+	Delivery   string `json:"delivery,omitempty"`    // How to notify them, unset uses their profile's settings
+	NTFYTopic  string `json:"ntfy_topic,omitempty"`  // Their personal NTFY topic
+	WebhookURL string `json:"webhook_url,omitempty"` // Their HTTPS webhook endpoint
+	Priority   int    `json:"priority,omitempty"`    // NTFY priority 1-5, 0 uses their profile's
+	MinPlayers int    `json:"min_players,omitempty"` // Only notify once this many people are waiting
+
+	// Set while the subscriber hasn't proven they own the NTFY topic yet
+	Pending        bool      `json:"pending,omitempty"`
+	VerifyCode     string    `json:"verify_code,omitempty"`
+	VerifyExpires  time.Time `json:"verify_expires,omitzero"`
+	VerifyAttempts int       `json:"verify_attempts,omitempty"` // Wrong codes tried so far
+
+	// Set on time-limited subscriptions, which are removed once they expire
+	Duration  string    `json:"duration,omitempty"` // How long it lasts, like "3d", used to renew it
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+
+	// When to notify them, in their profile's time zone. None means any time.
+	Availability []AvailabilityWindow `json:"availability,omitempty"`
+}
+
+// UsesProfile reports whether the subscription has no delivery settings of
+// its own and is delivered however its owner's profile says
+func (sub GameSubscription) UsesProfile() bool {
+	return sub.Delivery == "" && sub.NTFYTopic == "" && sub.WebhookURL == ""
+}
+
+// DeliveryMethod returns how the subscriber wants to be notified
+func (sub GameSubscription) DeliveryMethod() string {
+	if sub.Delivery == "" {
+		// Subscriptions made before delivery methods existed are all NTFY
+		return DeliveryNTFY
+	}
+	return sub.Delivery
+}
+
+// Target returns the destination for the subscription's delivery method
+func (sub GameSubscription) Target() string {
+	switch sub.DeliveryMethod() {
+	case DeliveryDiscord:
+		return sub.UserID
+	case DeliveryWebhook:
+		return sub.WebhookURL
+	default:
+		return sub.NTFYTopic
+	}
+}
+
+// AvailableAt reports whether t is inside one of the subscription's
+// availability windows, in the subscriber's time zone
+func (sub GameSubscription) AvailableAt(t time.Time, loc *time.Location) bool {
+	if len(sub.Availability) == 0 {
+		return true
+	}
+	local := t.In(loc)
+	for _, window := range sub.Availability {
+		if window.Contains(local) {
+			return true
+		}
+	}
+	return false
+}
+
+// flushInterval is how often changed subscriptions are written to the store
+const flushInterval = 2 * time.Second
+
+// MaxVerifyAttempts is how many wrong codes a pending NTFY topic survives
+const MaxVerifyAttempts = 5
+
+// subscriptionKey identifies a subscription
+type subscriptionKey struct {
+	GuildID string
+	UserID  string
+	Game    string
+}
+
+// gameKey identifies a game in a guild
+type gameKey struct {
+	GuildID string
+	Game    string
+}
+
+// keyOf returns the key a subscription is stored under
+func keyOf(sub GameSubscription) subscriptionKey {
+	return subscriptionKey{GuildID: sub.GuildID, UserID: sub.UserID, Game: sub.Game}
 }
 
-// SubscriptionManager manages game subscriptions
+// SubscriptionManager manages game subscriptions. Changes are kept in memory
+// and written to the store in the background, call Flush or Close to write
+// them out straight away.
 type SubscriptionManager struct {
-	subscriptions []GameSubscription
-	filePath      string
+	subscriptions map[subscriptionKey]GameSubscription
+	byUser        map[string]map[subscriptionKey]bool  // User ID -> their subscriptions in every guild
+	byGame        map[gameKey]map[subscriptionKey]bool // Guild and game -> its subscriptions
+	verified      map[gameKey]int                      // Guild and game -> how many of its subscriptions are verified
+	store         Store
 	mutex         sync.RWMutex
+
+	dirty       bool       // Changed since the last save
+	saveErr     error      // Why the last save failed, nil if it succeeded
+	saveMutex   sync.Mutex // Keeps saves in order
+	stopFlusher chan struct{}
+	flusherDone chan struct{}
 }
 
-// NewSubscriptionManager creates a new subscription manager
-func NewSubscriptionManager(filePath string) *SubscriptionManager {
+// NewSubscriptionManager creates a new subscription manager backed by a store.
+// It fails if the stored subscriptions can't be loaded, rather than starting
+// empty and overwriting them.
+func NewSubscriptionManager(store Store) (*SubscriptionManager, error) {
 	sm := &SubscriptionManager{
-		subscriptions: make([]GameSubscription, 0),
-		filePath:      filePath,
+		subscriptions: make(map[subscriptionKey]GameSubscription),
+		byUser:        make(map[string]map[subscriptionKey]bool),
+		byGame:        make(map[gameKey]map[subscriptionKey]bool),
+		verified:      make(map[gameKey]int),
+		store:         store,
+		stopFlusher:   make(chan struct{}),
+		flusherDone:   make(chan struct{}),
+	}
+	if err := sm.load(); err != nil {
+		return nil, fmt.Errorf("error loading subscriptions: %v", err)
+	}
+
+	go sm.runFlusher()
+	return sm, nil
+}
+
+// Close writes out any pending changes and closes the underlying store
+func (sm *SubscriptionManager) Close() error {
+	close(sm.stopFlusher)
+	<-sm.flusherDone
+
+	err := sm.Flush()
+	if closeErr := sm.store.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Flush writes the subscriptions to the store if they changed
+func (sm *SubscriptionManager) Flush() error {
+	sm.saveMutex.Lock()
+	defer sm.saveMutex.Unlock()
+
+	sm.mutex.Lock()
+	if !sm.dirty {
+		sm.mutex.Unlock()
+		return nil
+	}
+	snapshot := sm.snapshot()
+	sm.dirty = false
+	sm.mutex.Unlock()
+
+	// Save without holding the lock so lookups aren't blocked by the disk
+	err := sm.store.Save(snapshot)
+	sm.mutex.Lock()
+	sm.saveErr = err
+	if err != nil {
+		sm.dirty = true
+	}
+	sm.mutex.Unlock()
+	return err
+}
+
+// LastSaveError returns why the last save failed, or nil if it succeeded.
+// Changes that failed to save are still held in memory and retried.
+func (sm *SubscriptionManager) LastSaveError() error {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	return sm.saveErr
+}
+
+// runFlusher saves changes every flushInterval until Close is called
+func (sm *SubscriptionManager) runFlusher() {
+	defer close(sm.flusherDone)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sm.stopFlusher:
+			return
+		case <-ticker.C:
+			if err := sm.Flush(); err != nil {
+				log.Printf("Error saving subscriptions, will retry: %v", err)
+			}
+		}
 	}
-	sm.loadFromFile()
-	return sm
 }
 
 // Subscribe adds a user's subscription to a game
-func (sm *SubscriptionManager) Subscribe(userID, username, game, ntfyTopic string) error {
+func (sm *SubscriptionManager) Subscribe(newSub GameSubscription) error {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
 
 	// Check if already subscribed
-	for _, sub := range sm.subscriptions {
-		if sub.UserID == userID && sub.Game == game {
-			return fmt.Errorf("already subscribed to %s", game)
+	if _, exists := sm.subscriptions[keyOf(newSub)]; exists {
+		return fmt.Errorf("already subscribed to %s", newSub.Game)
+	}
+
+	sm.put(newSub)
+	return nil
+}
+
+// Unsubscribe removes a user's subscription to a game in a guild
+func (sm *SubscriptionManager) Unsubscribe(guildID, userID, game string) error {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	key := subscriptionKey{GuildID: guildID, UserID: userID, Game: game}
+	if _, exists := sm.subscriptions[key]; !exists {
+		return fmt.Errorf("not subscribed to %s", game)
+	}
+
+	sm.remove(key)
+	return nil
+}
+
+// SetAvailability replaces the times a subscription notifies its subscriber
+func (sm *SubscriptionManager) SetAvailability(guildID, userID, game string, windows []AvailabilityWindow) error {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	key := subscriptionKey{GuildID: guildID, UserID: userID, Game: game}
+	sub, exists := sm.subscriptions[key]
+	if !exists {
+		return fmt.Errorf("not subscribed to %s", game)
+	}
+
+	sub.Availability = windows
+	sm.put(sub)
+	return nil
+}
+
+// Verify confirms a user's pending subscriptions that were sent the given code
+func (sm *SubscriptionManager) Verify(userID, code string) ([]GameSubscription, error) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	var verified []GameSubscription
+	now := time.Now()
+	for key := range sm.byUser[userID] {
+		sub := sm.subscriptions[key]
+		if !sub.Pending || sub.VerifyCode != code || now.After(sub.VerifyExpires) {
+			continue
+		}
+		sub.Pending = false
+		sub.VerifyCode = ""
+		sub.VerifyExpires = time.Time{}
+		sub.VerifyAttempts = 0
+		sm.put(sub)
+		verified = append(verified, sub)
+	}
+
+	if len(verified) == 0 {
+		return nil, fmt.Errorf("no pending subscription matches that code")
+	}
+	sortSubscriptions(verified)
+	return verified, nil
+}
+
+// FailVerification counts a wrong code against the user's pending
+// subscriptions. Those that reach MaxVerifyAttempts are removed and returned,
+// so codes can't be guessed.
+func (sm *SubscriptionManager) FailVerification(userID string) []GameSubscription {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	var removed []GameSubscription
+	for key := range sm.byUser[userID] {
+		sub := sm.subscriptions[key]
+		if !sub.Pending {
+			continue
+		}
+		sub.VerifyAttempts++
+		if sub.VerifyAttempts >= MaxVerifyAttempts {
+			removed = append(removed, sub)
+			sm.remove(key)
+			continue
+		}
+		sm.put(sub)
+	}
+	sortSubscriptions(removed)
+	return removed
+}
+
+// RemoveExpiredPending removes pending subscriptions whose verification code
+// has expired and returns them
+func (sm *SubscriptionManager) RemoveExpiredPending(now time.Time) ([]GameSubscription, error) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	var expired []GameSubscription
+	for key, sub := range sm.subscriptions {
+		if sub.Pending && now.After(sub.VerifyExpires) {
+			expired = append(expired, sub)
+			sm.remove(key)
+		}
+	}
+	sortSubscriptions(expired)
+	return expired, nil
+}
+
+// RemoveExpired removes time-limited subscriptions that have run out and
+// returns them
+func (sm *SubscriptionManager) RemoveExpired(now time.Time) ([]GameSubscription, error) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	var expired []GameSubscription
+	for key, sub := range sm.subscriptions {
+		if !sub.ExpiresAt.IsZero() && now.After(sub.ExpiresAt) {
+			expired = append(expired, sub)
+			sm.remove(key)
+		}
+	}
+	sortSubscriptions(expired)
+	return expired, nil
+}
+
+// IsTopicVerified reports whether the user already owns an NTFY topic through
+// a verified subscription
+func (sm *SubscriptionManager) IsTopicVerified(userID, topic string) bool {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+
+	for key := range sm.byUser[userID] {
+		sub := sm.subscriptions[key]
+		if sub.DeliveryMethod() == DeliveryNTFY && sub.NTFYTopic == topic && !sub.Pending {
+			return true
 		}
 	}
+	return false
+}
 
-	// Add new subscription
-	newSub := GameSubscription{
-		UserID:    userID,
-		Username:  username,
-		Game:      game,
-		NTFYTopic: ntfyTopic,
+// AssignGuild moves subscriptions made before subscriptions were scoped to a
+// guild into the given guild, and returns how many were moved
+func (sm *SubscriptionManager) AssignGuild(guildID string) (int, error) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	assigned := 0
+	for key, sub := range sm.subscriptions {
+		if sub.GuildID != "" {
+			continue
+		}
+		sm.remove(key)
+		sub.GuildID = guildID
+		// Drop it if the guild already has the same subscription
+		if _, exists := sm.subscriptions[keyOf(sub)]; !exists {
+			sm.put(sub)
+		}
+		assigned++
 	}
-	sm.subscriptions = append(sm.subscriptions, newSub)
 
-	return sm.saveToFile()
+	return assigned, nil
+}
+
+// CountUnassigned returns how many subscriptions don't belong to a guild yet,
+// see AssignGuild
+func (sm *SubscriptionManager) CountUnassigned() int {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+
+	unassigned := 0
+	for key := range sm.subscriptions {
+		if key.GuildID == "" {
+			unassigned++
+		}
+	}
+	return unassigned
 }
 
-// Unsubscribe removes a user's subscription to a game
-func (sm *SubscriptionManager) Unsubscribe(userID, game string) error {
+// RenameGame moves every subscription to a game over to another game ID, and
+// returns how many were moved
+func (sm *SubscriptionManager) RenameGame(from, to string) int {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
 
-	for i, sub := range sm.subscriptions {
-		if sub.UserID == userID && sub.Game == game {
-			// Remove subscription
-			sm.subscriptions = append(sm.subscriptions[:i], sm.subscriptions[i+1:]...)
-			return sm.saveToFile()
+	var keys []subscriptionKey
+	for game, gameKeys := range sm.byGame {
+		if game.Game != from {
+			continue
+		}
+		for key := range gameKeys {
+			keys = append(keys, key)
 		}
 	}
 
-	return fmt.Errorf("not subscribed to %s", game)
+	for _, key := range keys {
+		sub := sm.subscriptions[key]
+		sm.remove(key)
+		sub.Game = to
+		// Drop it if the user is already subscribed under the new ID
+		if _, exists := sm.subscriptions[keyOf(sub)]; !exists {
+			sm.put(sub)
+		}
+	}
+	return len(keys)
 }
 
-// GetSubscriptions returns all subscriptions for a user
-func (sm *SubscriptionManager) GetSubscriptions(userID string) []GameSubscription {
+// GetSubscriptions returns all of a user's subscriptions in a guild
+func (sm *SubscriptionManager) GetSubscriptions(guildID, userID string) []GameSubscription {
 	sm.mutex.RLock()
 	defer sm.mutex.RUnlock()
 
 	var userSubs []GameSubscription
-	for _, sub := range sm.subscriptions {
-		if sub.UserID == userID {
-			userSubs = append(userSubs, sub)
+	for key := range sm.byUser[userID] {
+		if key.GuildID == guildID {
+			userSubs = append(userSubs, sm.subscriptions[key])
 		}
 	}
+	sortSubscriptions(userSubs)
 	return userSubs
 }
 
-// GetSubscribersForGame returns all subscribers for a specific game
-func (sm *SubscriptionManager) GetSubscribersForGame(game string) []GameSubscription {
+// GetUserSubscriptions returns a user's subscriptions in every guild
+func (sm *SubscriptionManager) GetUserSubscriptions(userID string) []GameSubscription {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+
+	var userSubs []GameSubscription
+	for key := range sm.byUser[userID] {
+		userSubs = append(userSubs, sm.subscriptions[key])
+	}
+	sortSubscriptions(userSubs)
+	return userSubs
+}
+
+// GetSubscribersForGame returns all verified subscribers for a game in a guild
+func (sm *SubscriptionManager) GetSubscribersForGame(guildID, game string) []GameSubscription {
 	sm.mutex.RLock()
 	defer sm.mutex.RUnlock()
 
 	var subscribers []GameSubscription
-	for _, sub := range sm.subscriptions {
-		if sub.Game == game {
+	for key := range sm.byGame[gameKey{GuildID: guildID, Game: game}] {
+		if sub := sm.subscriptions[key]; !sub.Pending {
 			subscribers = append(subscribers, sub)
 		}
 	}
 	return subscribers
 }
 
-// GetAllGames returns a list of all games people are subscribed to
-func (sm *SubscriptionManager) GetAllGames() []string {
+// GetAllGames returns a list of all games people in a guild are subscribed to
+func (sm *SubscriptionManager) GetAllGames(guildID string) []string {
+	counts := sm.CountByGame(guildID)
+
+	games := make([]string, 0, len(counts))
+	for game := range counts {
+		games = append(games, game)
+	}
+	sort.Strings(games)
+	return games
+}
+
+// CountByGame returns how many verified subscribers each game in a guild has
+func (sm *SubscriptionManager) CountByGame(guildID string) map[string]int {
 	sm.mutex.RLock()
 	defer sm.mutex.RUnlock()
 
-	gameSet := make(map[string]bool)
-	for _, sub := range sm.subscriptions {
-		gameSet[sub.Game] = true
+	counts := make(map[string]int)
+	for game, n := range sm.verified {
+		if game.GuildID == guildID {
+			counts[game.Game] = n
+		}
 	}
+	return counts
+}
 
-	games := make([]string, 0, len(gameSet))
-	for game := range gameSet {
-		games = append(games, game)
+// GetGuildSubscriptions returns every verified subscription in a guild
+func (sm *SubscriptionManager) GetGuildSubscriptions(guildID string) []GameSubscription {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+
+	var guildSubs []GameSubscription
+	for game, keys := range sm.byGame {
+		if game.GuildID != guildID {
+			continue
+		}
+		for key := range keys {
+			if sub := sm.subscriptions[key]; !sub.Pending {
+				guildSubs = append(guildSubs, sub)
+			}
+		}
 	}
-	return games
+	sortSubscriptions(guildSubs)
+	return guildSubs
 }
 
-// saveToFile saves subscriptions to JSON file
-func (sm *SubscriptionManager) saveToFile() error {
-	data, err := json.MarshalIndent(sm.subscriptions, "", "  ")
-	if err != nil {
-		return err
+// SubscriptionChanges is a set of changes applied together by Apply.
+// Subscriptions are matched by guild, user and game.
+type SubscriptionChanges struct {
+	Added   []GameSubscription
+	Updated []GameSubscription
+	Removed []GameSubscription
+}
+
+// Empty reports whether there is nothing to change
+func (c SubscriptionChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Updated) == 0 && len(c.Removed) == 0
+}
+
+// Diff works out the changes that turn a guild's subscriptions into the given
+// ones. Subscriptions missing from the list are only removed if replace is set.
+func (sm *SubscriptionManager) Diff(guildID string, incoming []GameSubscription, replace bool) SubscriptionChanges {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+
+	var changes SubscriptionChanges
+	seen := make(map[subscriptionKey]bool)
+	for _, sub := range incoming {
+		sub.GuildID = guildID
+		key := keyOf(sub)
+		seen[key] = true
+
+		current, exists := sm.subscriptions[key]
+		switch {
+		case !exists:
+			changes.Added = append(changes.Added, sub)
+		case !reflect.DeepEqual(current, sub):
+			changes.Updated = append(changes.Updated, sub)
+		}
 	}
-	return os.WriteFile(sm.filePath, data, 0644)
+
+	if replace {
+		for game, keys := range sm.byGame {
+			if game.GuildID != guildID {
+				continue
+			}
+			for key := range keys {
+				if !seen[key] {
+					changes.Removed = append(changes.Removed, sm.subscriptions[key])
+				}
+			}
+		}
+		sortSubscriptions(changes.Removed)
+	}
+	return changes
 }
 
-// loadFromFile loads subscriptions from JSON file
-func (sm *SubscriptionManager) loadFromFile() error {
-	data, err := os.ReadFile(sm.filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// File doesn't exist yet, that's okay
-			return nil
+// Apply makes all the changes and saves them straight away in a single write.
+// If saving fails nothing is changed.
+func (sm *SubscriptionManager) Apply(changes SubscriptionChanges) error {
+	sm.saveMutex.Lock()
+	defer sm.saveMutex.Unlock()
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	// Remember what is being replaced so it can be put back
+	previous := make(map[subscriptionKey]GameSubscription)
+	remember := func(key subscriptionKey) {
+		if _, saved := previous[key]; saved {
+			return
+		}
+		previous[key] = sm.subscriptions[key]
+	}
+
+	for _, sub := range changes.Removed {
+		remember(keyOf(sub))
+		sm.remove(keyOf(sub))
+	}
+	for _, sub := range append(changes.Added, changes.Updated...) {
+		remember(keyOf(sub))
+		sm.put(sub)
+	}
+
+	if err := sm.store.Save(sm.snapshot()); err != nil {
+		for key, sub := range previous {
+			sm.remove(key)
+			if sub.UserID != "" {
+				sm.put(sub)
+			}
 		}
 		return err
 	}
+	sm.dirty = false
+	sm.saveErr = nil
+	return nil
+}
+
+// put adds or replaces a subscription and indexes it. Must hold the write lock.
+func (sm *SubscriptionManager) put(sub GameSubscription) {
+	key := keyOf(sub)
+	sm.remove(key)
+	sm.subscriptions[key] = sub
+
+	if sm.byUser[sub.UserID] == nil {
+		sm.byUser[sub.UserID] = make(map[subscriptionKey]bool)
+	}
+	sm.byUser[sub.UserID][key] = true
+
+	game := gameKey{GuildID: sub.GuildID, Game: sub.Game}
+	if sm.byGame[game] == nil {
+		sm.byGame[game] = make(map[subscriptionKey]bool)
+	}
+	sm.byGame[game][key] = true
+	if !sub.Pending {
+		sm.verified[game]++
+	}
+
+	sm.dirty = true
+}
+
+// remove deletes a subscription and its index entries. Must hold the write lock.
+func (sm *SubscriptionManager) remove(key subscriptionKey) {
+	sub, exists := sm.subscriptions[key]
+	if !exists {
+		return
+	}
+	delete(sm.subscriptions, key)
+
+	delete(sm.byUser[key.UserID], key)
+	if len(sm.byUser[key.UserID]) == 0 {
+		delete(sm.byUser, key.UserID)
+	}
+
+	game := gameKey{GuildID: key.GuildID, Game: key.Game}
+	delete(sm.byGame[game], key)
+	if len(sm.byGame[game]) == 0 {
+		delete(sm.byGame, game)
+	}
+	if !sub.Pending {
+		sm.verified[game]--
+		if sm.verified[game] == 0 {
+			delete(sm.verified, game)
+		}
+	}
+
+	sm.dirty = true
+}
 
-	return json.Unmarshal(data, &sm.subscriptions)
+// snapshot returns every subscription in a stable order. Must hold the lock.
+func (sm *SubscriptionManager) snapshot() []GameSubscription {
+	subscriptions := make([]GameSubscription, 0, len(sm.subscriptions))
+	for _, sub := range sm.subscriptions {
+		subscriptions = append(subscriptions, sub)
+	}
+	sortSubscriptions(subscriptions)
+	return subscriptions
+}
+
+// sortSubscriptions orders subscriptions by guild, user and game
+func sortSubscriptions(subscriptions []GameSubscription) {
+	sort.Slice(subscriptions, func(i, j int) bool {
+		a, b := subscriptions[i], subscriptions[j]
+		if a.GuildID != b.GuildID {
+			return a.GuildID < b.GuildID
+		}
+		if a.UserID != b.UserID {
+			return a.UserID < b.UserID
+		}
+		return a.Game < b.Game
+	})
+}
+
+// load reads subscriptions from the store
+func (sm *SubscriptionManager) load() error {
+	subscriptions, err := sm.store.Load()
+	if err != nil {
+		return err
+	}
+	for _, sub := range subscriptions {
+		sm.put(sub)
+	}
+	sm.dirty = false
+	return nil
 }
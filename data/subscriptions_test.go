@@ -0,0 +1,214 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// benchmarkSize is how many subscriptions the benchmarks start with
+const benchmarkSize = 10_000
+
+// memoryStore keeps subscriptions in memory, so benchmarks measure the
+// manager rather than the disk
+type memoryStore struct {
+	subscriptions []GameSubscription
+	saveErr       error // Returned by Save instead of saving, if set
+}
+
+func (ms *memoryStore) Load() ([]GameSubscription, error) {
+	return ms.subscriptions, nil
+}
+
+func (ms *memoryStore) Save(subscriptions []GameSubscription) error {
+	if ms.saveErr != nil {
+		return ms.saveErr
+	}
+	ms.subscriptions = subscriptions
+	return nil
+}
+
+func (ms *memoryStore) Close() error {
+	return nil
+}
+
+func TestFlushReportsSaveError(t *testing.T) {
+	store := &memoryStore{saveErr: errors.New("disk full")}
+	sm, err := NewSubscriptionManager(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sm.Close()
+
+	if err := sm.Subscribe(GameSubscription{GuildID: "1", UserID: "alice", Game: "valorant"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.Flush(); err == nil {
+		t.Fatal("flush succeeded, want the save error")
+	}
+	if sm.LastSaveError() == nil {
+		t.Error("LastSaveError is nil after a failed save")
+	}
+
+	// The change is kept and saved once the store works again
+	store.saveErr = nil
+	if err := sm.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if sm.LastSaveError() != nil {
+		t.Errorf("LastSaveError is %v after a successful save", sm.LastSaveError())
+	}
+	if len(store.subscriptions) != 1 {
+		t.Errorf("saved %d subscriptions, want 1", len(store.subscriptions))
+	}
+}
+
+func TestFailVerificationDropsPending(t *testing.T) {
+	sm, err := NewSubscriptionManager(&memoryStore{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sm.Close()
+
+	pending := GameSubscription{GuildID: "1", UserID: "alice", Game: "valorant", Pending: true, VerifyCode: "123456"}
+	verified := GameSubscription{GuildID: "1", UserID: "alice", Game: "minecraft"}
+	for _, sub := range []GameSubscription{pending, verified} {
+		if err := sm.Subscribe(sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for attempt := 1; attempt < MaxVerifyAttempts; attempt++ {
+		if removed := sm.FailVerification("alice"); len(removed) != 0 {
+			t.Fatalf("attempt %d removed %+v", attempt, removed)
+		}
+	}
+	removed := sm.FailVerification("alice")
+	if len(removed) != 1 || removed[0].Game != "valorant" {
+		t.Errorf("removed %+v, want the pending subscription", removed)
+	}
+	if subs := sm.GetUserSubscriptions("alice"); len(subs) != 1 || subs[0].Game != "minecraft" {
+		t.Errorf("kept %+v, want only the verified subscription", subs)
+	}
+}
+
+// benchmarkSubscription returns the nth of a spread of subscriptions over
+// two guilds and every default game
+func benchmarkSubscription(n int) GameSubscription {
+	return GameSubscription{
+		GuildID:  fmt.Sprintf("guild-%d", n%2),
+		UserID:   fmt.Sprintf("user-%d", n),
+		Username: fmt.Sprintf("player%d", n),
+		Game:     DefaultGames[n%len(DefaultGames)].ID,
+	}
+}
+
+// newBenchmarkManager returns a manager holding benchmarkSize subscriptions
+func newBenchmarkManager(b *testing.B) *SubscriptionManager {
+	b.Helper()
+
+	store := &memoryStore{}
+	for n := range benchmarkSize {
+		store.subscriptions = append(store.subscriptions, benchmarkSubscription(n))
+	}
+	sm, err := NewSubscriptionManager(store)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { sm.Close() })
+	return sm
+}
+
+func BenchmarkSubscribe(b *testing.B) {
+	sm := newBenchmarkManager(b)
+
+	n := benchmarkSize
+	for b.Loop() {
+		if err := sm.Subscribe(benchmarkSubscription(n)); err != nil {
+			b.Fatal(err)
+		}
+		n++
+	}
+}
+
+func BenchmarkGetSubscribersForGame(b *testing.B) {
+	sm := newBenchmarkManager(b)
+
+	for b.Loop() {
+		if len(sm.GetSubscribersForGame("guild-0", "valorant")) == 0 {
+			b.Fatal("no subscribers")
+		}
+	}
+}
+
+func BenchmarkCountByGame(b *testing.B) {
+	sm := newBenchmarkManager(b)
+
+	for b.Loop() {
+		if len(sm.CountByGame("guild-0")) == 0 {
+			b.Fatal("no games")
+		}
+	}
+}
+
+// The baselines below work the way the manager did before it was indexed and
+// saved in the background, to show the difference
+
+func BenchmarkSubscribeSavingEachChange(b *testing.B) {
+	store := NewJSONStore(b.TempDir() + "/subscriptions.json")
+	var initial []GameSubscription
+	for n := range benchmarkSize {
+		initial = append(initial, benchmarkSubscription(n))
+	}
+	if err := store.Save(initial); err != nil {
+		b.Fatal(err)
+	}
+	sm, err := NewSubscriptionManager(store)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { sm.Close() })
+
+	n := benchmarkSize
+	for b.Loop() {
+		if err := sm.Subscribe(benchmarkSubscription(n)); err != nil {
+			b.Fatal(err)
+		}
+		if err := sm.Flush(); err != nil {
+			b.Fatal(err)
+		}
+		n++
+	}
+}
+
+func BenchmarkGetSubscribersForGameScan(b *testing.B) {
+	subscriptions := newBenchmarkManager(b).snapshot()
+
+	for b.Loop() {
+		var subscribers []GameSubscription
+		for _, sub := range subscriptions {
+			if sub.GuildID == "guild-0" && sub.Game == "valorant" && !sub.Pending {
+				subscribers = append(subscribers, sub)
+			}
+		}
+		if len(subscribers) == 0 {
+			b.Fatal("no subscribers")
+		}
+	}
+}
+
+func BenchmarkCountByGameScan(b *testing.B) {
+	subscriptions := newBenchmarkManager(b).snapshot()
+
+	for b.Loop() {
+		counts := make(map[string]int)
+		for _, sub := range subscriptions {
+			if sub.GuildID == "guild-0" && !sub.Pending {
+				counts[sub.Game]++
+			}
+		}
+		if len(counts) == 0 {
+			b.Fatal("no games")
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Names of the messages that can be customized
+const (
+	TemplateAnnouncement      = "announcement"
+	TemplateNotificationTitle = "notification-title"
+	TemplateNotificationBody  = "notification-body"
+	TemplateSubscribed        = "subscribed"
+	TemplateUnsubscribed      = "unsubscribed"
+)
+
+// DefaultTemplates are used for any message a guild hasn't customized
+var DefaultTemplates = map[string]string{
+	TemplateAnnouncement:      "@everyone 🎮 **{{.User}}** is looking for people to play! What game do you want to play?",
+	TemplateNotificationTitle: "{{.User}} is looking for {{.Game}} players",
+	TemplateNotificationBody:  "{{.User}} is waiting in {{.VoiceChannel}}. Jump in!",
+	TemplateSubscribed: "✅ Successfully subscribed to **{{.Game}}** notifications!\n" +
+		"Delivery: {{.Delivery}}\n" +
+		"You'll get notified when someone wants to play!",
+	TemplateUnsubscribed: "✅ Successfully unsubscribed from **{{.Game}}** notifications!",
+}
+
+// maxMessageLength is the longest message Discord accepts
+const maxMessageLength = 2000
+
+// TemplateVars are the variables available to message templates
+type TemplateVars struct {
+	User         string // Name of the user the message is about
+	Game         string // Display name of the game, e.g. "Rocket League"
+	GameID       string // Game slug, e.g. "rocket-league"
+	VoiceChannel string // Name of the LFG voice channel
+	Participants int    // People currently in the LFG voice channel
+	PartySize    int    // How many people make a full party for the game, 0 if unknown
+	Delivery     string // Where notifications go, e.g. "Discord DM"
+}
+
+// sampleVars are used to check a template works before it is saved
+var sampleVars = TemplateVars{
+	User:         "player1",
+	Game:         "Rocket League",
+	GameID:       "rocket-league",
+	VoiceChannel: "LFG",
+	Participants: 2,
+	PartySize:    3,
+	Delivery:     "Discord DM",
+}
+
+// TemplateNames returns the names of all customizable messages, sorted
+func TemplateNames() []string {
+	names := make([]string, 0, len(DefaultTemplates))
+	for name := range DefaultTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TemplateManager manages per-guild message templates
+type TemplateManager struct {
+	templates map[string]map[string]string // guild ID -> template name -> text
+	filePath  string
+	mutex     sync.RWMutex
+}
+
+// NewTemplateManager creates a new template manager. It fails if the stored
+// templates can't be loaded, rather than starting empty and overwriting them.
+func NewTemplateManager(filePath string) (*TemplateManager, error) {
+	tm := &TemplateManager{
+		templates: make(map[string]map[string]string),
+		filePath:  filePath,
+	}
+	if err := tm.loadFromFile(); err != nil {
+		return nil, fmt.Errorf("error loading templates: %v", err)
+	}
+	return tm, nil
+}
+
+// Set validates and saves a guild's custom template
+func (tm *TemplateManager) Set(guildID, name, text string) error {
+	if _, exists := DefaultTemplates[name]; !exists {
+		return fmt.Errorf("unknown template %s", name)
+	}
+	if err := validateTemplate(text); err != nil {
+		return err
+	}
+
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	if tm.templates[guildID] == nil {
+		tm.templates[guildID] = make(map[string]string)
+	}
+	tm.templates[guildID][name] = text
+
+	return tm.saveToFile()
+}
+
+// Reset removes a guild's custom template so the default is used again
+func (tm *TemplateManager) Reset(guildID, name string) error {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	if _, exists := tm.templates[guildID][name]; !exists {
+		return fmt.Errorf("%s isn't customized", name)
+	}
+	delete(tm.templates[guildID], name)
+
+	return tm.saveToFile()
+}
+
+// Get returns the template text a guild uses for a message and whether it is
+// customized. A nil manager always uses the defaults.
+func (tm *TemplateManager) Get(guildID, name string) (string, bool) {
+	if tm == nil {
+		return DefaultTemplates[name], false
+	}
+
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+
+	if text, exists := tm.templates[guildID][name]; exists {
+		return text, true
+	}
+	return DefaultTemplates[name], false
+}
+
+// Render renders a guild's message, falling back to the default template if
+// the custom one fails
+func (tm *TemplateManager) Render(guildID, name string, vars TemplateVars) string {
+	text, custom := tm.Get(guildID, name)
+
+	rendered, err := renderTemplate(text, vars)
+	if err != nil && custom {
+		log.Printf("Error rendering %s template for guild %s, using default: %v", name, guildID, err)
+		rendered, err = renderTemplate(DefaultTemplates[name], vars)
+	}
+	if err != nil {
+		log.Printf("Error rendering default %s template: %v", name, err)
+	}
+	return rendered
+}
+
+// validateTemplate checks a template parses and renders with every variable
+func validateTemplate(text string) error {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, sampleVars); err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+	if rendered.Len() > maxMessageLength {
+		return fmt.Errorf("template renders longer than Discord's %d character limit", maxMessageLength)
+	}
+	return nil
+}
+
+// renderTemplate renders template text with the given variables
+func renderTemplate(text string, vars TemplateVars) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, vars); err != nil {
+		return "", err
+	}
+	return rendered.String(), nil
+}
+
+// saveToFile saves templates to JSON file
+func (tm *TemplateManager) saveToFile() error {
+	data, err := json.MarshalIndent(tm.templates, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(tm.filePath, data, 0644)
+}
+
+// loadFromFile loads templates from JSON file
+func (tm *TemplateManager) loadFromFile() error {
+	data, err := os.ReadFile(tm.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// File doesn't exist yet, that's okay
+			return nil
+		}
+		return err
+	}
+
+	if err := json.Unmarshal(data, &tm.templates); err != nil {
+		return unreadable(tm.filePath, err)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package data
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvHeader is the column layout of exported subscriptions
+var csvHeader = []string{
+	"user_id", "username", "game", "delivery", "ntfy_topic", "webhook_url", "priority", "min_players",
+	"duration", "expires_at", "availability",
+}
+
+// csvWindowSeparator separates availability windows in a CSV cell
+const csvWindowSeparator = "; "
+
+// EncodeSubscriptionsJSON formats subscriptions for export as JSON
+func EncodeSubscriptionsJSON(subscriptions []GameSubscription) ([]byte, error) {
+	exported := make([]GameSubscription, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		exported = append(exported, exportable(sub))
+	}
+	return json.MarshalIndent(exported, "", "  ")
+}
+
+// DecodeSubscriptionsJSON reads subscriptions exported as JSON
+func DecodeSubscriptionsJSON(contents []byte) ([]GameSubscription, error) {
+	var subscriptions []GameSubscription
+	if err := json.Unmarshal(contents, &subscriptions); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	return subscriptions, nil
+}
+
+// csvFormulaPrefixes are the characters that make spreadsheets treat a cell
+// as a formula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// escapeCSVCell quotes a cell that a spreadsheet would run as a formula, like
+// a username of "=HYPERLINK(...)", by putting a ' in front of it
+func escapeCSVCell(cell string) string {
+	if cell != "" && strings.ContainsRune(csvFormulaPrefixes, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// unescapeCSVCell undoes escapeCSVCell
+func unescapeCSVCell(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
+
+// EncodeSubscriptionsCSV formats subscriptions for export as CSV
+func EncodeSubscriptionsCSV(subscriptions []GameSubscription) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(csvHeader)
+	for _, sub := range subscriptions {
+		var expiresAt string
+		if !sub.ExpiresAt.IsZero() {
+			expiresAt = sub.ExpiresAt.UTC().Format(time.RFC3339)
+		}
+		windows := make([]string, 0, len(sub.Availability))
+		for _, window := range sub.Availability {
+			windows = append(windows, window.String())
+		}
+
+		record := []string{
+			sub.UserID,
+			sub.Username,
+			sub.Game,
+			sub.Delivery,
+			sub.NTFYTopic,
+			sub.WebhookURL,
+			strconv.Itoa(sub.Priority),
+			strconv.Itoa(sub.MinPlayers),
+			sub.Duration,
+			expiresAt,
+			strings.Join(windows, csvWindowSeparator),
+		}
+		for i, cell := range record {
+			record[i] = escapeCSVCell(cell)
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// DecodeSubscriptionsCSV reads subscriptions exported as CSV. Columns are
+// matched by the header, so they can be in any order.
+func DecodeSubscriptionsCSV(contents []byte) ([]GameSubscription, error) {
+	r := csv.NewReader(bytes.NewReader(contents))
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, required := range []string{"user_id", "game"} {
+		if _, exists := columns[required]; !exists {
+			return nil, fmt.Errorf("invalid CSV: missing %s column", required)
+		}
+	}
+
+	var subscriptions []GameSubscription
+	for row := 2; ; row++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+
+		field := func(name string) string {
+			if i, exists := columns[name]; exists && i < len(record) {
+				return unescapeCSVCell(record[i])
+			}
+			return ""
+		}
+		number := func(name string) (int, error) {
+			value := field(name)
+			if value == "" {
+				return 0, nil
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return 0, fmt.Errorf("row %d: %s %q is not a number", row, name, value)
+			}
+			return n, nil
+		}
+
+		sub := GameSubscription{
+			UserID:     field("user_id"),
+			Username:   field("username"),
+			Game:       field("game"),
+			Delivery:   field("delivery"),
+			NTFYTopic:  field("ntfy_topic"),
+			WebhookURL: field("webhook_url"),
+			Duration:   field("duration"),
+		}
+		if sub.Priority, err = number("priority"); err != nil {
+			return nil, err
+		}
+		if sub.MinPlayers, err = number("min_players"); err != nil {
+			return nil, err
+		}
+		if value := field("expires_at"); value != "" {
+			if sub.ExpiresAt, err = time.Parse(time.RFC3339, value); err != nil {
+				return nil, fmt.Errorf("row %d: expires_at %q is not a time like 2024-01-02T15:04:05Z", row, value)
+			}
+			sub.ExpiresAt = sub.ExpiresAt.UTC()
+		}
+		for _, value := range strings.Split(field("availability"), ";") {
+			if strings.TrimSpace(value) == "" {
+				continue
+			}
+			window, err := ParseAvailabilityWindow(value)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: availability: %v", row, err)
+			}
+			sub.Availability = append(sub.Availability, window)
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+	return subscriptions, nil
+}
+
+// exportable strips the fields that don't belong in an export
+func exportable(sub GameSubscription) GameSubscription {
+	sub.GuildID = ""
+	sub.Pending = false
+	sub.VerifyCode = ""
+	sub.VerifyExpires = time.Time{}
+	sub.VerifyAttempts = 0
+	return sub
+}
@@ -0,0 +1,55 @@
+package data
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionsCSVRoundTrip(t *testing.T) {
+	subscriptions := []GameSubscription{
+		{
+			UserID:     "1",
+			Username:   "alice",
+			Game:       "valorant",
+			Delivery:   DeliveryNTFY,
+			NTFYTopic:  "alice-topic",
+			Priority:   4,
+			MinPlayers: 3,
+			Duration:   "3d",
+			ExpiresAt:  time.Date(2026, 10, 21, 18, 30, 0, 0, time.UTC),
+			Availability: []AvailabilityWindow{
+				{Days: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, TimeWindow: TimeWindow{Start: 19 * 60, End: 23 * 60}},
+				{Days: []time.Weekday{time.Friday, time.Sunday}, TimeWindow: TimeWindow{Start: 22 * 60, End: 2 * 60}},
+				{TimeWindow: TimeWindow{Start: 12 * 60, End: 13 * 60}},
+			},
+		},
+		{UserID: "2", Username: "bob", Game: "minecraft"},
+		{UserID: "3", Username: "=SUM(A1:A9)", Game: "minecraft"},
+	}
+
+	contents, err := EncodeSubscriptionsCSV(subscriptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(contents, []byte(",=SUM")) {
+		t.Errorf("username was exported as a formula:\n%s", contents)
+	}
+	decoded, err := DecodeSubscriptionsCSV(contents)
+	if err != nil {
+		t.Fatalf("decoding %s: %v", contents, err)
+	}
+	if !reflect.DeepEqual(decoded, subscriptions) {
+		t.Errorf("round trip changed subscriptions\ngot  %+v\nwant %+v", decoded, subscriptions)
+	}
+}
+
+func TestDecodeSubscriptionsCSVRejectsBadWindows(t *testing.T) {
+	for _, availability := range []string{"weekdays 19:00", "someday 19:00-23:00", "weekdays 19:00-19:00"} {
+		contents := []byte("user_id,game,availability\n1,valorant," + availability + "\n")
+		if _, err := DecodeSubscriptionsCSV(contents); err == nil {
+			t.Errorf("%q: got no error", availability)
+		}
+	}
+}
@@ -4,68 +4,368 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
+	"time"
 
 	"discord-bot/config"
+	"discord-bot/data"
+	"discord-bot/notify"
 
 	"github.com/bwmarrin/discordgo"
 )
 
 // Manager handles all LFG (Looking for Game) functionality
 type Manager struct {
-	Config *config.Config
+	Config        *config.Config
+	Subscriptions *data.SubscriptionManager
+	Profiles      *data.ProfileManager
+	History       *data.HistoryManager
+	Templates     *data.TemplateManager
+	Guilds        *data.GuildManager
+	Games         *data.GameCatalog
+	Outbox        *notify.Outbox
+	Callbacks     *notify.CallbackServer // nil when notification buttons are disabled
+	Throttle      *Throttle
+	Stats         *Stats
+
+	sessions     map[string]*Session // Active sessions by voice channel ID
+	sessionMutex sync.Mutex
+}
+
+// Dependencies are the stores and services the LFG manager works with
+type Dependencies struct {
+	Subscriptions *data.SubscriptionManager
+	Profiles      *data.ProfileManager
+	History       *data.HistoryManager
+	Templates     *data.TemplateManager
+	Guilds        *data.GuildManager
+	Games         *data.GameCatalog
+	Outbox        *notify.Outbox
+	Callbacks     *notify.CallbackServer
 }
 
 // New creates a new LFG manager
-func New(cfg *config.Config) *Manager {
+func New(cfg *config.Config, deps Dependencies) *Manager {
 	return &Manager{
-		Config: cfg,
+		Config:        cfg,
+		Subscriptions: deps.Subscriptions,
+		Profiles:      deps.Profiles,
+		History:       deps.History,
+		Templates:     deps.Templates,
+		Guilds:        deps.Guilds,
+		Games:         deps.Games,
+		Outbox:        deps.Outbox,
+		Callbacks:     deps.Callbacks,
+		Throttle:      NewThrottle(cfg.NotifyCooldown, cfg.InitiatorCooldown),
+		Stats:         NewStats(),
+		sessions:      make(map[string]*Session),
 	}
 }
 
-// IsLFGChannel checks if a channel is designated for "Looking for Game"
+// IsLFGChannel checks if a channel is designated for "Looking for Game" in its guild
 func (m *Manager) IsLFGChannel(channel *discordgo.Channel) bool {
-	lfgChannelId := m.Config.LFGChannelID
+	lfgChannelId := m.guildSettings(channel.GuildID).LFGChannelID
 	if lfgChannelId != "" && lfgChannelId == channel.ID {
 		return true
 	}
 	return false
 }
 
+// guildSettings returns a guild's LFG settings. Each channel the guild hasn't
+// set with /admin lfg falls back to the one from the environment.
+func (m *Manager) guildSettings(guildID string) data.GuildSettings {
+	settings := data.GuildSettings{GuildID: guildID}
+	if m.Guilds != nil {
+		settings = m.Guilds.Get(guildID)
+	}
+	if settings.LFGChannelID == "" {
+		settings.LFGChannelID = m.Config.LFGChannelID
+	}
+	if settings.AnnouncementChannelID == "" {
+		settings.AnnouncementChannelID = m.Config.LFGAnnouncementChannelID
+	}
+	return settings
+}
+
 // HandleUserJoinedLFG processes when someone joins an LFG channel
 func (m *Manager) HandleUserJoinedLFG(s *discordgo.Session, user *discordgo.User, channel *discordgo.Channel) {
 	fmt.Printf("🎮 %s joined %s - Looking for game!\n", user.Username, channel.Name)
 
+	occupants := countOccupants(s, channel)
+
 	// Send a message to announce the LFG
-	m.announceUserLookingForGame(s, user, channel)
+	announcement := m.announceUserLookingForGame(s, user, channel, occupants)
+
+	m.sessionMutex.Lock()
+	defer m.sessionMutex.Unlock()
+
+	session, started := m.joinSession(user, channel)
+	if started {
+		session.Announcement = announcement
+	}
+
+	// Until there is a game selection interface, a session is assumed to be
+	// for the games the people in it are subscribed to
+	if m.Subscriptions != nil {
+		for _, sub := range m.Subscriptions.GetSubscriptions(channel.GuildID, user.ID) {
+			session.games[sub.Game] = true
+		}
+	}
+
+	// Push notifications to everyone whose party size has been reached
+	m.NotifySubscribers(s, session, channel, occupants)
 
 	// TODO: Add game selection interface
-	// TODO: Add NTFY notifications to subscribers
+}
+
+// NotifySubscribers queues a notification for every subscriber of the
+// session's games who hasn't been notified yet and whose minimum party size
+// has been reached. Must hold sessionMutex.
+func (m *Manager) NotifySubscribers(s *discordgo.Session, session *Session, voiceChannel *discordgo.Channel, occupants int) {
+	if m.Subscriptions == nil || m.Outbox == nil {
+		return
+	}
+
+	now := time.Now()
+	initiator := session.Initiator
+	origin := notify.Origin{
+		GuildID:       voiceChannel.GuildID,
+		InitiatorID:   initiator.ID,
+		InitiatorName: initiator.Username,
+	}
+
+	// The whole fan-out is saved at once, rather than rewriting the outbox
+	// and history files for every subscriber
+	var batch []notify.OutboxEntry
+	var suppressed []data.HistoryEntry
+
+	for _, game := range session.Games() {
+		msg := m.buildNotification(initiator, voiceChannel, game, occupants)
+
+		queued := 0
+		// Reasons that can pass, like being in DND, are only recorded once
+		// and the subscriber is reconsidered when the next person joins
+		skip := func(sub data.GameSubscription, reason string) {
+			if session.skipped[sub.UserID] != reason {
+				session.skipped[sub.UserID] = reason
+				suppressed = append(suppressed, m.suppress(sub, origin, reason))
+			}
+		}
+
+		for _, sub := range m.Subscriptions.GetSubscribersForGame(voiceChannel.GuildID, game) {
+			// Don't notify the user who started the session, or anyone twice
+			if sub.UserID == initiator.ID || session.notified[sub.UserID] {
+				continue
+			}
+
+			// Wait until the party is big enough for them, a later join may get there
+			if occupants < sub.MinPlayers {
+				continue
+			}
+
+			// Fill in delivery settings the subscription shares with the profile
+			profile := m.profile(sub.UserID)
+			sub = profile.Resolve(sub)
+
+			// Bouncing in and out of the channel only notifies people once per
+			// cooldown, which lasts the whole session
+			if session.Throttled {
+				session.notified[sub.UserID] = true
+				suppressed = append(suppressed, m.suppress(sub, origin, SuppressedInitiator))
+				continue
+			}
+
+			// No point inviting someone who's already there
+			if isInVoiceChannel(s, sub.UserID, voiceChannel) {
+				skip(sub, SuppressedInVoice)
+				continue
+			}
+
+			if m.Config.PresenceIntent && profile.SkipWhenDND && isDoNotDisturb(s, voiceChannel.GuildID, sub.UserID) {
+				skip(sub, SuppressedDND)
+				continue
+			}
+
+			// Only notify inside the times they picked for this game
+			if !sub.AvailableAt(now, profile.Location()) {
+				skip(sub, SuppressedAway)
+				continue
+			}
+
+			// Quiet hours that hold notifications for a digest are queued below
+			if _, quiet := profile.QuietUntil(now); quiet && profile.QuietHoursMode() != data.QuietDigest {
+				skip(sub, SuppressedQuiet)
+				continue
+			}
+
+			if !m.Throttle.AllowSubscriber(sub.UserID, game, initiator.ID, now) {
+				skip(sub, SuppressedCooldown)
+				continue
+			}
+
+			session.notified[sub.UserID] = true
+			batch = append(batch, m.newNotification(sub, profile, msg, origin, session.Announcement, now))
+			queued++
+		}
+
+		if queued > 0 {
+			fmt.Printf("📬 Queued %d %s notification(s)\n", queued, game)
+		}
+	}
+
+	// Delivery and retries happen in the outbox, a failure here means it couldn't be persisted
+	if err := m.Outbox.EnqueueAll(batch); err != nil {
+		log.Printf("Error queueing %d notification(s): %v", len(batch), err)
+	}
+	if m.History != nil {
+		if err := m.History.Record(suppressed...); err != nil {
+			log.Printf("Error recording notification history: %v", err)
+		}
+	}
+}
+
+// newNotification personalizes a notification for a subscriber, ready to be
+// handed to the outbox
+func (m *Manager) newNotification(sub data.GameSubscription, profile data.UserProfile, msg notify.Message, origin notify.Origin, announcement *discordgo.Message, now time.Time) notify.OutboxEntry {
+	// Each subscriber picks their own priority
+	msg.Priority = sub.Priority
+	m.Stats.RecordQueued(origin.GuildID)
+
+	// Hold it for the digest during quiet hours, NotifySubscribers already
+	// skipped anyone whose quiet hours drop notifications
+	if until, quiet := profile.QuietUntil(now); quiet {
+		fmt.Printf("🌙 %s is in quiet hours (%s)\n", sub.Username, profile.QuietHoursMode())
+		return notify.NewDigestEntry(sub, msg, origin, until)
+	}
+
+	// Let "I'm in" report back so we can tell the channel they're coming
+	if m.Callbacks != nil && announcement != nil {
+		msg.Actions = append([]notify.Action{m.Callbacks.AcceptAction(notify.Callback{
+			UserID:    sub.UserID,
+			Username:  sub.Username,
+			Game:      sub.Game,
+			ChannelID: announcement.ChannelID,
+			MessageID: announcement.ID,
+		})}, msg.Actions[1:]...)
+	}
+
+	return notify.NewEntry(sub, msg, origin)
+}
+
+// profile returns a subscriber's profile, or an empty one if profiles aren't set up
+func (m *Manager) profile(userID string) data.UserProfile {
+	if m.Profiles == nil {
+		return data.UserProfile{UserID: userID}
+	}
+	return m.Profiles.Get(userID)
+}
+
+// suppress counts a notification that was deliberately not sent and returns
+// it for the history log
+func (m *Manager) suppress(sub data.GameSubscription, origin notify.Origin, reason string) data.HistoryEntry {
+	m.Stats.RecordSuppressed(origin.GuildID, reason)
+
+	return data.HistoryEntry{
+		GuildID:       origin.GuildID,
+		UserID:        sub.UserID,
+		Username:      sub.Username,
+		Game:          sub.Game,
+		InitiatorID:   origin.InitiatorID,
+		InitiatorName: origin.InitiatorName,
+		Backend:       sub.DeliveryMethod(),
+		Outcome:       data.OutcomeSuppressed,
+		Reason:        reason,
+	}
+}
+
+// buildNotification creates the push notification for an LFG session
+func (m *Manager) buildNotification(user *discordgo.User, voiceChannel *discordgo.Channel, game string, occupants int) notify.Message {
+	voiceLink := voiceChannelLink(voiceChannel)
+	vars := data.TemplateVars{
+		User:         user.Username,
+		Game:         m.Games.DisplayName(game),
+		GameID:       game,
+		PartySize:    m.partySize(game),
+		VoiceChannel: voiceChannel.Name,
+		Participants: occupants,
+	}
+
+	return notify.Message{
+		Title: m.Templates.Render(voiceChannel.GuildID, data.TemplateNotificationTitle, vars),
+		Body:  m.Templates.Render(voiceChannel.GuildID, data.TemplateNotificationBody, vars),
+		Tags:  []string{m.Games.Emoji(game)},
+		Click: voiceLink,
+		Actions: []notify.Action{
+			{Action: "view", Label: "I'm in", URL: voiceLink, Clear: true},
+			{Action: "view", Label: "Open Discord", URL: voiceLink},
+			{Action: "broadcast", Label: "Not now", Clear: true},
+		},
+	}
+}
+
+// partySize returns how many people make a full party for a game, or 0 if unknown
+func (m *Manager) partySize(game string) int {
+	if m.Games == nil {
+		return 0
+	}
+	if entry, exists := m.Games.Resolve(game); exists {
+		return entry.PartySize
+	}
+	return 0
+}
+
+// HandleAccepted posts a reply to the LFG announcement when a subscriber presses "I'm in"
+func (m *Manager) HandleAccepted(s *discordgo.Session, cb notify.Callback) {
+	message := fmt.Sprintf("🏃 **%s** is on their way!", cb.Username)
+	reference := &discordgo.MessageReference{
+		MessageID: cb.MessageID,
+		ChannelID: cb.ChannelID,
+	}
+
+	_, err := s.ChannelMessageSendReply(cb.ChannelID, message, reference)
+	if err != nil {
+		log.Printf("Error posting LFG reply for %s: %v", cb.Username, err)
+		return
+	}
+	fmt.Printf("🏃 %s accepted the %s invite\n", cb.Username, cb.Game)
+}
+
+// voiceChannelLink returns a deep link that opens the voice channel in the Discord app
+func voiceChannelLink(voiceChannel *discordgo.Channel) string {
+	return fmt.Sprintf("discord://discord.com/channels/%s/%s", voiceChannel.GuildID, voiceChannel.ID)
 }
 
 // announceUserLookingForGame sends a message when someone is looking for a game
-func (m *Manager) announceUserLookingForGame(s *discordgo.Session, user *discordgo.User, voiceChannel *discordgo.Channel) {
-	message := fmt.Sprintf("@everyone 🎮 **%s** is looking for people to play! What game do you want to play?", user.Username)
+// and returns it, or nil if it couldn't be sent
+func (m *Manager) announceUserLookingForGame(s *discordgo.Session, user *discordgo.User, voiceChannel *discordgo.Channel, occupants int) *discordgo.Message {
+	message := m.Templates.Render(voiceChannel.GuildID, data.TemplateAnnouncement, data.TemplateVars{
+		User:         user.Username,
+		VoiceChannel: voiceChannel.Name,
+		Participants: occupants,
+	})
 
 	// Use configured announcement channel or find one automatically
 	var textChannelID string
-	if m.Config.LFGAnnouncementChannelID != "" {
-		textChannelID = m.Config.LFGAnnouncementChannelID
+	if settings := m.guildSettings(voiceChannel.GuildID); settings.AnnouncementChannelID != "" {
+		textChannelID = settings.AnnouncementChannelID
 		fmt.Printf("📢 Using configured announcement channel: %s\n", textChannelID)
 	} else {
 		textChannelID = m.findAnnouncementChannel(s, voiceChannel.GuildID)
 		fmt.Printf("📢 Auto-found announcement channel: %s\n", textChannelID)
 	}
 
-	if textChannelID != "" {
-		_, err := s.ChannelMessageSend(textChannelID, message)
-		if err != nil {
-			log.Printf("Error sending LFG announcement: %v", err)
-		} else {
-			fmt.Printf("📢 Sent LFG announcement with @everyone tag\n")
-		}
-	} else {
+	if textChannelID == "" {
 		log.Println("Warning: Could not find a suitable text channel for LFG announcement")
+		return nil
+	}
+
+	announcement, err := s.ChannelMessageSend(textChannelID, message)
+	if err != nil {
+		log.Printf("Error sending LFG announcement: %v", err)
+		return nil
 	}
+	fmt.Printf("📢 Sent LFG announcement with @everyone tag\n")
+	return announcement
 }
 
 // findAnnouncementChannel finds the best text channel to send LFG announcements
@@ -107,6 +407,3 @@ func (m *Manager) findAnnouncementChannel(s *discordgo.Session, guildID string)
 
 // TODO: Future methods to add:
 // - SelectGame(user, game) - Let user select what game they want to play
-// - NotifySubscribers(game, user) - Send NTFY notifications to game subscribers
-// - GetActiveSession(channelID) - Get current LFG session for a channel
-// - CreateGameSession(user, game, channel) - Create a new game session
@@ -0,0 +1,97 @@
+package lfg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"discord-bot/config"
+	"discord-bot/data"
+	"discord-bot/notify"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestNotifySubscribersSkipsInitiator(t *testing.T) {
+	// Stands in for the NTFY server, reporting the topic of every post
+	topics := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		topics <- strings.TrimPrefix(r.URL.Path, "/")
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	subscriptions, err := data.NewSubscriptionManager(data.NewJSONStore(filepath.Join(dir, "subscriptions.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer subscriptions.Close()
+	for _, user := range []string{"alice", "bob"} {
+		err := subscriptions.Subscribe(data.GameSubscription{
+			GuildID:   "guild",
+			UserID:    user + "-id",
+			Username:  user,
+			Game:      "valorant",
+			Delivery:  data.DeliveryNTFY,
+			NTFYTopic: user + "-topic",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dispatcher := notify.NewDispatcher()
+	dispatcher.Register(data.DeliveryNTFY, notify.NewNTFY(server.URL))
+	outbox, err := notify.NewOutbox(filepath.Join(dir, "outbox.json"), dispatcher, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := data.NewTemplateManager(filepath.Join(dir, "templates.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	games, err := data.NewGameCatalog(filepath.Join(dir, "games.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(&config.Config{}, Dependencies{
+		Subscriptions: subscriptions,
+		Templates:     templates,
+		Games:         games,
+		Outbox:        outbox,
+	})
+
+	s, err := discordgo.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := &discordgo.User{ID: "alice-id", Username: "alice"}
+	channel := &discordgo.Channel{ID: "voice", GuildID: "guild", Name: "General", Type: discordgo.ChannelTypeGuildVoice}
+
+	m.sessionMutex.Lock()
+	session, _ := m.joinSession(alice, channel)
+	session.games["valorant"] = true
+	m.NotifySubscribers(s, session, channel, 1)
+	m.sessionMutex.Unlock()
+
+	pending := outbox.Stats("guild").Pending
+	if len(pending) != 1 || pending[0].Subscription.UserID != "bob-id" {
+		t.Fatalf("queued %+v, want only bob", pending)
+	}
+
+	outbox.Start()
+	defer outbox.Stop()
+	select {
+	case topic := <-topics:
+		if topic != "bob-topic" {
+			t.Errorf("sent to %q, want %q", topic, "bob-topic")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification was never sent")
+	}
+}
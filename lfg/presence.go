@@ -0,0 +1,23 @@
+package lfg
+
+import "github.com/bwmarrin/discordgo"
+
+// isInVoiceChannel reports whether a user is currently in the given voice channel
+func isInVoiceChannel(s *discordgo.Session, userID string, voiceChannel *discordgo.Channel) bool {
+	voiceState, err := s.State.VoiceState(voiceChannel.GuildID, userID)
+	if err != nil {
+		// Not in any voice channel we know of
+		return false
+	}
+	return voiceState.ChannelID == voiceChannel.ID
+}
+
+// isDoNotDisturb reports whether a user's Discord status is set to Do Not Disturb
+func isDoNotDisturb(s *discordgo.Session, guildID, userID string) bool {
+	presence, err := s.State.Presence(guildID, userID)
+	if err != nil {
+		// Presences only arrive with the guild presences intent
+		return false
+	}
+	return presence.Status == discordgo.StatusDoNotDisturb
+}
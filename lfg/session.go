@@ -0,0 +1,87 @@
+package lfg
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Session is an LFG session. It starts when someone joins an empty LFG
+// channel and ends when the channel is empty again.
+type Session struct {
+	ChannelID    string
+	Initiator    *discordgo.User
+	Announcement *discordgo.Message // First announcement of the session
+	StartedAt    time.Time
+	Throttled    bool // The initiator started another session too recently
+
+	games    map[string]bool   // Games anyone in the session is subscribed to
+	notified map[string]bool   // Subscribers who were notified, or can't be this session
+	skipped  map[string]string // Subscribers passed over for now and why, so it's only recorded once
+}
+
+// Games returns the games the session could be for, sorted
+func (session *Session) Games() []string {
+	games := make([]string, 0, len(session.games))
+	for game := range session.games {
+		games = append(games, game)
+	}
+	sort.Strings(games)
+	return games
+}
+
+// joinSession returns the channel's session, starting one with the user as
+// initiator if there isn't one yet. Must hold sessionMutex.
+func (m *Manager) joinSession(user *discordgo.User, voiceChannel *discordgo.Channel) (*Session, bool) {
+	if session, exists := m.sessions[voiceChannel.ID]; exists {
+		return session, false
+	}
+
+	now := time.Now()
+	session := &Session{
+		ChannelID: voiceChannel.ID,
+		Initiator: user,
+		StartedAt: now,
+		Throttled: !m.Throttle.AllowInitiator(user.ID, now),
+		games:     make(map[string]bool),
+		notified:  make(map[string]bool),
+		skipped:   make(map[string]string),
+	}
+	m.sessions[voiceChannel.ID] = session
+	return session, true
+}
+
+// HandleUserLeftLFG ends the channel's session once everyone has left
+func (m *Manager) HandleUserLeftLFG(s *discordgo.Session, channel *discordgo.Channel) {
+	m.sessionMutex.Lock()
+	defer m.sessionMutex.Unlock()
+
+	if _, exists := m.sessions[channel.ID]; !exists {
+		return
+	}
+	if countOccupants(s, channel) == 0 {
+		delete(m.sessions, channel.ID)
+		fmt.Printf("👋 LFG session in %s ended\n", channel.Name)
+	}
+}
+
+// countOccupants returns how many people are in a voice channel, not counting the bot
+func countOccupants(s *discordgo.Session, voiceChannel *discordgo.Channel) int {
+	guild, err := s.State.Guild(voiceChannel.GuildID)
+	if err != nil {
+		return 0
+	}
+
+	s.State.RLock()
+	defer s.State.RUnlock()
+
+	count := 0
+	for _, voiceState := range guild.VoiceStates {
+		if voiceState.ChannelID == voiceChannel.ID && voiceState.UserID != s.State.User.ID {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,79 @@
+package lfg
+
+import (
+	"sort"
+	"sync"
+)
+
+// Reasons a notification wasn't sent
+const (
+	SuppressedCooldown  = "subscriber cooldown"
+	SuppressedInitiator = "initiator throttle"
+	SuppressedQuiet     = "quiet hours"
+	SuppressedInVoice   = "already in voice"
+	SuppressedDND       = "do not disturb"
+	SuppressedAway      = "outside availability"
+)
+
+// Stats counts what happened to each guild's LFG notifications since the bot started
+type Stats struct {
+	queued     map[string]int            // Guild ID -> notifications queued
+	suppressed map[string]map[string]int // Guild ID -> reason -> notifications suppressed
+	mutex      sync.Mutex
+}
+
+// StatsSnapshot is a point-in-time copy of the stats
+type StatsSnapshot struct {
+	Queued     int
+	Suppressed map[string]int
+}
+
+// NewStats creates an empty stats counter
+func NewStats() *Stats {
+	return &Stats{
+		queued:     make(map[string]int),
+		suppressed: make(map[string]map[string]int),
+	}
+}
+
+// RecordQueued counts a notification handed to the outbox
+func (st *Stats) RecordQueued(guildID string) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	st.queued[guildID]++
+}
+
+// RecordSuppressed counts a notification that wasn't sent and why
+func (st *Stats) RecordSuppressed(guildID, reason string) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	if st.suppressed[guildID] == nil {
+		st.suppressed[guildID] = make(map[string]int)
+	}
+	st.suppressed[guildID][reason]++
+}
+
+// Snapshot returns a copy of a guild's current counts
+func (st *Stats) Snapshot(guildID string) StatsSnapshot {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	suppressed := make(map[string]int, len(st.suppressed[guildID]))
+	for reason, count := range st.suppressed[guildID] {
+		suppressed[reason] = count
+	}
+	return StatsSnapshot{
+		Queued:     st.queued[guildID],
+		Suppressed: suppressed,
+	}
+}
+
+// Reasons returns the suppression reasons in the snapshot, sorted
+func (snap StatsSnapshot) Reasons() []string {
+	reasons := make([]string, 0, len(snap.Suppressed))
+	for reason := range snap.Suppressed {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	return reasons
+}
@@ -0,0 +1,85 @@
+package lfg
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Throttle keeps people from being spammed when someone bounces in and out
+// of the LFG channel
+type Throttle struct {
+	SubscriberCooldown time.Duration // Between notifications for the same subscriber, game and initiator
+	InitiatorCooldown  time.Duration // Between notification rounds started by the same initiator
+
+	subscribers map[string]time.Time
+	initiators  map[string]time.Time
+	mutex       sync.Mutex
+}
+
+// NewThrottle creates a new throttle
+func NewThrottle(subscriberCooldown, initiatorCooldown time.Duration) *Throttle {
+	return &Throttle{
+		SubscriberCooldown: subscriberCooldown,
+		InitiatorCooldown:  initiatorCooldown,
+		subscribers:        make(map[string]time.Time),
+		initiators:         make(map[string]time.Time),
+	}
+}
+
+// AllowInitiator reports whether the initiator may trigger notifications now,
+// and starts their cooldown if so
+func (t *Throttle) AllowInitiator(initiatorID string, now time.Time) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.prune(now)
+	return t.allow(t.initiators, initiatorID, t.InitiatorCooldown, now)
+}
+
+// AllowSubscriber reports whether a subscriber may be notified about an
+// initiator's game now, and starts the cooldown if so
+func (t *Throttle) AllowSubscriber(subscriberID, game, initiatorID string, now time.Time) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	key := subscriberID + "|" + game + "|" + initiatorID
+	return t.allow(t.subscribers, key, t.SubscriberCooldown, now)
+}
+
+// ForgetUser drops every cooldown involving a user
+func (t *Throttle) ForgetUser(userID string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.initiators, userID)
+	for key := range t.subscribers {
+		parts := strings.Split(key, "|")
+		if parts[0] == userID || parts[len(parts)-1] == userID {
+			delete(t.subscribers, key)
+		}
+	}
+}
+
+// allow checks and records a key against its cooldown, must hold the mutex
+func (t *Throttle) allow(last map[string]time.Time, key string, cooldown time.Duration, now time.Time) bool {
+	if at, exists := last[key]; exists && now.Sub(at) < cooldown {
+		return false
+	}
+	last[key] = now
+	return true
+}
+
+// prune forgets cooldowns that have run out, must hold the mutex
+func (t *Throttle) prune(now time.Time) {
+	for key, at := range t.subscribers {
+		if now.Sub(at) >= t.SubscriberCooldown {
+			delete(t.subscribers, key)
+		}
+	}
+	for key, at := range t.initiators {
+		if now.Sub(at) >= t.InitiatorCooldown {
+			delete(t.initiators, key)
+		}
+	}
+}
@@ -11,25 +11,126 @@ import (
 	"discord-bot/commands"
 	"discord-bot/config"
 	"discord-bot/data"
+	"discord-bot/notify"
 )
 
 func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run starts the bot and blocks until it is asked to shut down. Errors are
+// returned rather than exiting, so deferred saves still happen.
+func run() error {
 	// Load configuration
 	cfg := config.Load()
 
+	// Initialize user profiles
+	profiles, err := data.NewProfileManager("profiles.json")
+	if err != nil {
+		return err
+	}
+	commands.Profiles = profiles
+
 	// Initialize subscription manager
-	commands.SubManager = data.NewSubscriptionManager("subscriptions.json")
+	store, err := openSubscriptionStore(cfg)
+	if err != nil {
+		return fmt.Errorf("error opening subscription store: %v", err)
+	}
+	commands.SubManager, err = data.NewSubscriptionManager(store)
+	if err != nil {
+		return err
+	}
+	// Write out any subscription changes that haven't been flushed yet on shutdown
+	defer func() {
+		if err := commands.SubManager.Close(); err != nil {
+			log.Printf("Error saving subscriptions on shutdown: %v", err)
+		}
+	}()
+
+	// Subscriptions from before the bot served several servers belong to the configured one
+	if cfg.LegacyGuildID != "" {
+		assigned, err := commands.SubManager.AssignGuild(cfg.LegacyGuildID)
+		if err != nil {
+			return fmt.Errorf("error assigning subscriptions to guild: %v", err)
+		}
+		if assigned > 0 {
+			fmt.Printf("📦 Assigned %d existing subscription(s) to server %s\n", assigned, cfg.LegacyGuildID)
+		}
+	} else if unassigned := commands.SubManager.CountUnassigned(); unassigned > 0 {
+		log.Printf("Warning: %d subscription(s) from before multi-server support don't belong to any server "+
+			"and will never be notified. Set LEGACY_GUILD_ID to the server they were made in.", unassigned)
+	}
+
+	// Initialize the game catalog and move subscriptions made under an alias to the game
+	commands.Games, err = data.NewGameCatalog("games.json")
+	if err != nil {
+		return err
+	}
+	for _, game := range commands.Games.All() {
+		for _, alias := range game.Aliases {
+			if moved := commands.SubManager.RenameGame(alias, game.ID); moved > 0 {
+				fmt.Printf("📦 Moved %d %s subscription(s) to %s\n", moved, alias, game.ID)
+			}
+		}
+	}
+
+	// Subscriptions that repeat the same delivery settings share them through the profile
+	folded, err := data.FoldDeliveryIntoProfiles(commands.SubManager, commands.Profiles)
+	if err != nil {
+		return fmt.Errorf("error moving delivery settings into profiles: %v", err)
+	}
+	if folded > 0 {
+		fmt.Printf("📦 Moved delivery settings of %d subscription(s) into profiles\n", folded)
+	}
+
+	// Initialize notification history
+	commands.History, err = data.NewHistoryManager("history.json", 5000)
+	if err != nil {
+		return err
+	}
+
+	// Initialize the audit log of changes to personal data
+	commands.Audit, err = data.NewAuditLog("audit.json", "audit.key")
+	if err != nil {
+		return err
+	}
+
+	// Initialize guild message templates
+	commands.Templates, err = data.NewTemplateManager("templates.json")
+	if err != nil {
+		return err
+	}
+
+	// Initialize per-server LFG settings
+	commands.Guilds, err = data.NewGuildManager("guilds.json")
+	if err != nil {
+		return err
+	}
 
 	// Create bot
 	b, err := bot.New(cfg)
 	if err != nil {
-		log.Fatal("Error creating bot: ", err)
+		return fmt.Errorf("error creating bot: %v", err)
 	}
 
+	commands.Notifier = b.Notifier
+
+	// Initialize notification outbox, resuming anything left from the last run
+	b.Outbox, err = notify.NewOutbox("outbox.json", b.Notifier, cfg.NotifyMaxAttempts)
+	if err != nil {
+		return fmt.Errorf("error creating outbox: %v", err)
+	}
+	b.Outbox.History = commands.History
+	commands.Outbox = b.Outbox
+	b.Outbox.Start()
+	defer b.Outbox.Stop()
+
 	// Start the bot
 	err = b.Start()
 	if err != nil {
-		log.Fatal("Error starting bot: ", err)
+		return fmt.Errorf("error starting bot: %v", err)
 	}
 	defer b.Stop()
 
@@ -39,4 +140,28 @@ func main() {
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	<-sc
+	return nil
+}
+
+// openSubscriptionStore opens the configured subscription store. Switching to
+// SQLite imports the existing JSON file the first time.
+func openSubscriptionStore(cfg *config.Config) (data.Store, error) {
+	if cfg.StorageBackend != "sqlite" {
+		return data.NewJSONStore("subscriptions.json"), nil
+	}
+
+	store, err := data.NewSQLiteStore(cfg.SQLitePath)
+	if err != nil {
+		return nil, err
+	}
+
+	imported, err := data.ImportJSON("subscriptions.json", store)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("error importing subscriptions.json: %v", err)
+	}
+	if imported > 0 {
+		fmt.Printf("📦 Imported %d subscription(s) from subscriptions.json into %s\n", imported, cfg.SQLitePath)
+	}
+	return store, nil
 }
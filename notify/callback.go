@@ -0,0 +1,168 @@
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// callbackTTL is how long a notification's buttons keep working
+const callbackTTL = 3 * time.Hour
+
+// callbackPath is where button presses are posted, followed by the callback ID
+const callbackPath = "/ntfy/callback/"
+
+// Callback describes a notification whose action buttons report back to the bot
+type Callback struct {
+	UserID    string
+	Username  string
+	Game      string
+	ChannelID string // Channel of the LFG announcement
+	MessageID string // The LFG announcement message
+	token     string
+	expiresAt time.Time
+}
+
+// CallbackServer receives action button presses from NTFY notifications.
+// Every notification gets its own token, so a button can only act on the
+// notification it was sent with.
+type CallbackServer struct {
+	Addr      string
+	PublicURL string
+	OnAccept  func(cb Callback) // Called when someone presses "I'm in"
+
+	pending map[string]Callback
+	mutex   sync.Mutex
+	server  *http.Server
+}
+
+// NewCallbackServer creates a callback server listening on addr and reachable at publicURL
+func NewCallbackServer(addr, publicURL string) *CallbackServer {
+	return &CallbackServer{
+		Addr:      addr,
+		PublicURL: strings.TrimRight(publicURL, "/"),
+		pending:   make(map[string]Callback),
+	}
+}
+
+// AcceptAction registers a callback and returns the "I'm in" button that triggers it
+func (c *CallbackServer) AcceptAction(cb Callback) Action {
+	id := randomHex(8)
+	cb.token = randomHex(32)
+	cb.expiresAt = time.Now().Add(callbackTTL)
+
+	c.mutex.Lock()
+	c.pruneExpired()
+	c.pending[id] = cb
+	c.mutex.Unlock()
+
+	return Action{
+		Action:  "http",
+		Label:   "I'm in",
+		URL:     c.PublicURL + callbackPath + id,
+		Method:  http.MethodPost,
+		Headers: map[string]string{"Authorization": "Bearer " + cb.token},
+		Clear:   true,
+	}
+}
+
+// ForgetUser cancels the buttons on a user's notifications
+func (c *CallbackServer) ForgetUser(userID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for id, cb := range c.pending {
+		if cb.UserID == userID {
+			delete(c.pending, id)
+		}
+	}
+}
+
+// Handler returns the HTTP handler for callbacks
+func (c *CallbackServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+callbackPath+"{id}", c.handleCallback)
+	return mux
+}
+
+// Start starts listening for callbacks in the background
+func (c *CallbackServer) Start() {
+	c.server = &http.Server{
+		Addr:              c.Addr,
+		Handler:           c.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		fmt.Printf("🔔 Listening for notification callbacks on %s\n", c.Addr)
+		err := c.server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("Error running callback server: %v", err)
+		}
+	}()
+}
+
+// Stop shuts the callback server down
+func (c *CallbackServer) Stop() {
+	if c.server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c.server.Shutdown(ctx)
+}
+
+// handleCallback verifies a button press and hands it to OnAccept
+func (c *CallbackServer) handleCallback(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	c.mutex.Lock()
+	cb, exists := c.pending[id]
+	valid := exists && time.Now().Before(cb.expiresAt) &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(cb.token)) == 1
+	if valid {
+		// Each button press only counts once
+		delete(c.pending, id)
+	}
+	c.mutex.Unlock()
+
+	if !valid {
+		http.Error(w, "unknown or expired notification", http.StatusNotFound)
+		return
+	}
+
+	if c.OnAccept != nil {
+		c.OnAccept(cb)
+	}
+	fmt.Fprintln(w, "See you there!")
+}
+
+// pruneExpired drops callbacks whose buttons have expired, must hold the mutex
+func (c *CallbackServer) pruneExpired() {
+	now := time.Now()
+	for id, cb := range c.pending {
+		if now.After(cb.expiresAt) {
+			delete(c.pending, id)
+		}
+	}
+}
+
+// isCallbackAction reports whether an action reports back to a callback server
+func isCallbackAction(action Action) bool {
+	return action.Action == "http" && strings.Contains(action.URL, callbackPath)
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
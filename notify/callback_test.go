@@ -0,0 +1,138 @@
+package notify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"discord-bot/data"
+)
+
+// pressButton simulates NTFY sending an action button's request to the callback server
+func pressButton(t *testing.T, server *httptest.Server, action Action, token string) int {
+	t.Helper()
+
+	path := strings.TrimPrefix(action.URL, "https://bot.example")
+	req, err := http.NewRequest(action.Method, server.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// tokenOf returns the bearer token an action button sends
+func tokenOf(action Action) string {
+	return strings.TrimPrefix(action.Headers["Authorization"], "Bearer ")
+}
+
+func TestCallbackButtonPresses(t *testing.T) {
+	callbacks := NewCallbackServer("", "https://bot.example/")
+	var accepted []Callback
+	callbacks.OnAccept = func(cb Callback) {
+		accepted = append(accepted, cb)
+	}
+	server := httptest.NewServer(callbacks.Handler())
+	defer server.Close()
+
+	action := callbacks.AcceptAction(Callback{UserID: "1", Username: "alice", Game: "valorant"})
+	if action.Method != http.MethodPost || !strings.HasPrefix(action.URL, "https://bot.example"+callbackPath) {
+		t.Fatalf("unexpected action %+v", action)
+	}
+
+	// A wrong token is rejected and doesn't use up the button
+	if status := pressButton(t, server, action, "wrong"); status != http.StatusNotFound {
+		t.Errorf("wrong token: got %d, want %d", status, http.StatusNotFound)
+	}
+	if len(accepted) != 0 {
+		t.Fatalf("wrong token triggered OnAccept")
+	}
+
+	if status := pressButton(t, server, action, tokenOf(action)); status != http.StatusOK {
+		t.Errorf("valid token: got %d, want %d", status, http.StatusOK)
+	}
+	if len(accepted) != 1 || accepted[0].UserID != "1" || accepted[0].Game != "valorant" {
+		t.Fatalf("valid token: accepted %+v", accepted)
+	}
+
+	// Each press only counts once
+	if status := pressButton(t, server, action, tokenOf(action)); status != http.StatusNotFound {
+		t.Errorf("replayed press: got %d, want %d", status, http.StatusNotFound)
+	}
+	if len(accepted) != 1 {
+		t.Errorf("replayed press triggered OnAccept again")
+	}
+}
+
+func TestCallbackExpires(t *testing.T) {
+	callbacks := NewCallbackServer("", "https://bot.example")
+	callbacks.OnAccept = func(cb Callback) {
+		t.Errorf("expired callback triggered OnAccept")
+	}
+	server := httptest.NewServer(callbacks.Handler())
+	defer server.Close()
+
+	action := callbacks.AcceptAction(Callback{UserID: "1"})
+	callbacks.mutex.Lock()
+	for id, cb := range callbacks.pending {
+		cb.expiresAt = time.Now().Add(-time.Minute)
+		callbacks.pending[id] = cb
+	}
+	callbacks.mutex.Unlock()
+
+	if status := pressButton(t, server, action, tokenOf(action)); status != http.StatusNotFound {
+		t.Errorf("expired callback: got %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestOutboxDropsCallbacksOnReload(t *testing.T) {
+	callbacks := NewCallbackServer("", "https://bot.example")
+	view := Action{Action: "view", Label: "Open Discord", URL: "https://discord.com/channels/1/2"}
+	accept := callbacks.AcceptAction(Callback{UserID: "1"})
+
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	outbox, err := NewOutbox(path, NewDispatcher(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := data.GameSubscription{UserID: "1", Game: "valorant", Delivery: data.DeliveryNTFY, NTFYTopic: "alice"}
+	if err := outbox.Enqueue(sub, Message{Title: "LFG", Actions: []Action{accept, view}}, Origin{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The callback token is never written to disk, but the running outbox keeps it
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(contents), callbackPath) {
+		t.Errorf("saved outbox contains a callback action:\n%s", contents)
+	}
+	if actions := outbox.Stats("").Pending[0].Message.Actions; len(actions) != 2 {
+		t.Errorf("running outbox has actions %+v, want both", actions)
+	}
+
+	// A restart loses the callbacks, so the reloaded entry mustn't offer the button
+	reloaded, err := NewOutbox(path, NewDispatcher(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := reloaded.Stats("").Pending
+	if len(pending) != 1 {
+		t.Fatalf("got %d pending entries, want 1", len(pending))
+	}
+	actions := pending[0].Message.Actions
+	if len(actions) != 1 || actions[0].Label != view.Label {
+		t.Errorf("reloaded actions %+v, want only %q", actions, view.Label)
+	}
+}
@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DiscordDM delivers notifications as direct messages from the bot
+type DiscordDM struct {
+	Session *discordgo.Session
+}
+
+// NewDiscordDM creates a new Discord DM backend using the bot session
+func NewDiscordDM(s *discordgo.Session) *DiscordDM {
+	return &DiscordDM{
+		Session: s,
+	}
+}
+
+// Send opens a DM channel with the user and posts the message there
+func (d *DiscordDM) Send(userID string, msg Message) (int, error) {
+	channel, err := d.Session.UserChannelCreate(userID)
+	if err != nil {
+		return 0, fmt.Errorf("error opening DM channel: %v", err)
+	}
+
+	content := msg.Body
+	if msg.Title != "" {
+		content = fmt.Sprintf("**%s**\n%s", msg.Title, msg.Body)
+	}
+	if msg.Click != "" {
+		content += "\n" + msg.Click
+	}
+
+	_, err = d.Session.ChannelMessageSend(channel.ID, content)
+	if err != nil {
+		return 0, fmt.Errorf("error sending DM: %v", err)
+	}
+	return 0, nil
+}
@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"discord-bot/data"
+)
+
+// NTFY priorities, also used as a general urgency hint by other backends
+const (
+	PriorityMin     = 1
+	PriorityLow     = 2
+	PriorityDefault = 3
+	PriorityHigh    = 4
+	PriorityUrgent  = 5
+)
+
+// Message is a notification delivered to a subscriber
+type Message struct {
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	Priority int      `json:"priority,omitempty"` // 1-5, 0 means the backend default
+	Tags     []string `json:"tags,omitempty"`     // NTFY tags, emoji short codes show as emoji
+	Click    string   `json:"click,omitempty"`    // URL opened when the notification is tapped
+	Actions  []Action `json:"actions,omitempty"`
+}
+
+// Action is a button shown on a notification
+type Action struct {
+	Action  string            `json:"action"` // "view", "http" or "broadcast"
+	Label   string            `json:"label"`
+	URL     string            `json:"url,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Clear   bool              `json:"clear,omitempty"` // Dismiss the notification when tapped
+}
+
+// Notifier delivers messages through a single delivery channel
+type Notifier interface {
+	// Send delivers msg to target and returns the HTTP status code of the
+	// delivery, or 0 for backends that don't talk HTTP
+	Send(target string, msg Message) (int, error)
+}
+
+// Dispatcher routes messages to the backend each subscription is set up for
+type Dispatcher struct {
+	backends map[string]Notifier
+	mutex    sync.RWMutex
+}
+
+// NewDispatcher creates a dispatcher with no backends registered
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		backends: make(map[string]Notifier),
+	}
+}
+
+// Register adds a backend for a delivery method
+func (d *Dispatcher) Register(method string, n Notifier) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.backends[method] = n
+}
+
+// Methods returns the delivery methods that have a backend configured
+func (d *Dispatcher) Methods() []string {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	methods := make([]string, 0, len(d.backends))
+	for method := range d.backends {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Send delivers a message through the subscription's delivery method
+func (d *Dispatcher) Send(sub data.GameSubscription, msg Message) (int, error) {
+	method := sub.DeliveryMethod()
+
+	d.mutex.RLock()
+	backend, exists := d.backends[method]
+	d.mutex.RUnlock()
+	if !exists {
+		return 0, fmt.Errorf("no backend configured for %s delivery", method)
+	}
+
+	return backend.Send(sub.Target(), msg)
+}
@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NTFY sends push notifications through an NTFY server (self-hosted or ntfy.sh)
+type NTFY struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewNTFY creates a new NTFY client for the given server URL
+func NewNTFY(baseURL string) *NTFY {
+	return &NTFY{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send publishes a message to an NTFY topic
+func (n *NTFY) Send(topic string, msg Message) (int, error) {
+	if topic == "" {
+		return 0, fmt.Errorf("no NTFY topic set")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.BaseURL+"/"+url.PathEscape(topic), strings.NewReader(msg.Body))
+	if err != nil {
+		return 0, fmt.Errorf("error creating NTFY request: %v", err)
+	}
+	if msg.Title != "" {
+		// Headers must be ASCII, so emoji in titles are RFC 2047 encoded (NTFY decodes them)
+		req.Header.Set("Title", mime.BEncoding.Encode("utf-8", msg.Title))
+	}
+	if msg.Priority != 0 {
+		req.Header.Set("Priority", strconv.Itoa(msg.Priority))
+	}
+	if len(msg.Tags) > 0 {
+		req.Header.Set("Tags", strings.Join(msg.Tags, ","))
+	}
+	if msg.Click != "" {
+		req.Header.Set("Click", msg.Click)
+	}
+	if len(msg.Actions) > 0 {
+		// NTFY accepts actions as a JSON array, which avoids quoting labels like "I'm in"
+		actions, err := json.Marshal(msg.Actions)
+		if err != nil {
+			return 0, fmt.Errorf("error encoding NTFY actions: %v", err)
+		}
+		req.Header.Set("Actions", string(actions))
+	}
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error sending to NTFY topic %s: %v", topic, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("NTFY returned %s for topic %s", resp.Status, topic)
+	}
+	return resp.StatusCode, nil
+}
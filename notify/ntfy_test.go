@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNTFYSendHeaders(t *testing.T) {
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contents, _ := io.ReadAll(r.Body)
+		got, body = r, string(contents)
+	}))
+	defer server.Close()
+
+	msg := Message{
+		Title:    "🎮 alice wants to play Valorant",
+		Body:     "Join them in General",
+		Priority: 4,
+		Tags:     []string{"video_game", "valorant"},
+		Click:    "https://discord.com/channels/1/2",
+		Actions: []Action{
+			{Action: "view", Label: "I'm in", URL: "https://discord.com/channels/1/2", Clear: true},
+			{Action: "broadcast", Label: "Not now", Clear: true},
+		},
+	}
+	status, err := NewNTFY(server.URL+"/").Send("bob's topic", msg)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if status != http.StatusOK {
+		t.Errorf("got status %d, want %d", status, http.StatusOK)
+	}
+
+	if got.Method != http.MethodPost || got.URL.EscapedPath() != "/bob%27s%20topic" {
+		t.Errorf("got %s %s, want POST to the escaped topic", got.Method, got.URL.EscapedPath())
+	}
+	if body != msg.Body {
+		t.Errorf("got body %q, want %q", body, msg.Body)
+	}
+
+	// Emoji can't go in a header as is, NTFY decodes the encoded word
+	title := got.Header.Get("Title")
+	if strings.ContainsFunc(title, func(r rune) bool { return r > 127 }) {
+		t.Errorf("title header %q isn't ASCII", title)
+	}
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(title); err != nil || decoded != msg.Title {
+		t.Errorf("title decodes to %q (%v), want %q", decoded, err, msg.Title)
+	}
+
+	if priority := got.Header.Get("Priority"); priority != "4" {
+		t.Errorf("got priority %q, want %q", priority, "4")
+	}
+	if tags := got.Header.Get("Tags"); tags != "video_game,valorant" {
+		t.Errorf("got tags %q, want %q", tags, "video_game,valorant")
+	}
+	if click := got.Header.Get("Click"); click != msg.Click {
+		t.Errorf("got click %q, want %q", click, msg.Click)
+	}
+
+	var actions []Action
+	if err := json.Unmarshal([]byte(got.Header.Get("Actions")), &actions); err != nil {
+		t.Fatalf("actions header isn't JSON: %v", err)
+	}
+	if len(actions) != 2 || actions[0].Label != "I'm in" || !actions[0].Clear || actions[1].Action != "broadcast" {
+		t.Errorf("got actions %+v, want %+v", actions, msg.Actions)
+	}
+}
+
+func TestNTFYSendLeavesOutEmptyHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer server.Close()
+
+	if _, err := NewNTFY(server.URL).Send("bob", Message{Body: "hi"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	for _, header := range []string{"Title", "Priority", "Tags", "Click", "Actions"} {
+		if value := got.Get(header); value != "" {
+			t.Errorf("got %s header %q for an empty field", header, value)
+		}
+	}
+}
+
+func TestNTFYSendErrorStatus(t *testing.T) {
+	for _, status := range []int{http.StatusForbidden, http.StatusTooManyRequests, http.StatusInternalServerError} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+
+		got, err := NewNTFY(server.URL).Send("bob", Message{Body: "hi"})
+		if err == nil {
+			t.Errorf("status %d: got no error", status)
+		}
+		if got != status {
+			t.Errorf("status %d: Send returned %d", status, got)
+		}
+		server.Close()
+	}
+}
+
+func TestNTFYSendNeedsTopic(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("sent a request with no topic")
+	}))
+	defer server.Close()
+
+	if _, err := NewNTFY(server.URL).Send("", Message{Body: "hi"}); err == nil {
+		t.Errorf("got no error for an empty topic")
+	}
+}
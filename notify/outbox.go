@@ -0,0 +1,505 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	mathrand "math/rand"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-bot/data"
+)
+
+// maxDeadLetters is how many failed notifications are kept for inspection
+const maxDeadLetters = 100
+
+// Origin describes the LFG session a notification is about
+type Origin struct {
+	GuildID       string `json:"guild_id,omitempty"`
+	InitiatorID   string `json:"initiator_id,omitempty"`
+	InitiatorName string `json:"initiator_name,omitempty"`
+}
+
+// OutboxEntry is a queued notification for a single subscriber
+type OutboxEntry struct {
+	ID           string                `json:"id"`
+	Subscription data.GameSubscription `json:"subscription"`
+	Message      Message               `json:"message"`
+	Origin       Origin                `json:"origin"`
+	Attempts     int                   `json:"attempts"`
+	NextAttempt  time.Time             `json:"next_attempt"`
+	LastError    string                `json:"last_error,omitempty"`
+	CreatedAt    time.Time             `json:"created_at"`
+	Digest       bool                  `json:"digest,omitempty"` // Held back to be delivered together with others
+}
+
+// OutboxStats summarizes the delivery state of a guild's notifications
+type OutboxStats struct {
+	Pending    []OutboxEntry
+	DeadLetter []OutboxEntry
+	Delivered  int // Since the bot started
+}
+
+// outboxFile is the on-disk layout of the outbox
+type outboxFile struct {
+	Pending    []OutboxEntry `json:"pending"`
+	DeadLetter []OutboxEntry `json:"dead_letter"`
+}
+
+// Outbox durably queues notifications and retries failed deliveries with
+// exponential backoff until they succeed or run out of attempts
+type Outbox struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Workers     int                  // How many deliveries are sent at once
+	History     *data.HistoryManager // Optional log of every delivery attempt
+
+	notifier   *Dispatcher
+	filePath   string
+	pending    []OutboxEntry
+	deadLetter []OutboxEntry
+	delivered  map[string]int // Guild ID -> deliveries since startup
+	mutex      sync.Mutex
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewOutbox creates an outbox stored at filePath, loading any entries left
+// over from a previous run
+func NewOutbox(filePath string, notifier *Dispatcher, maxAttempts int) (*Outbox, error) {
+	o := &Outbox{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   30 * time.Second,
+		MaxDelay:    30 * time.Minute,
+		Workers:     8,
+		notifier:    notifier,
+		filePath:    filePath,
+		pending:     make([]OutboxEntry, 0),
+		deadLetter:  make([]OutboxEntry, 0),
+		delivered:   make(map[string]int),
+		wake:        make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	if err := o.loadFromFile(); err != nil {
+		return nil, fmt.Errorf("error loading outbox: %v", err)
+	}
+	if len(o.pending) > 0 {
+		fmt.Printf("📬 Resuming %d pending notification(s) from outbox\n", len(o.pending))
+	}
+	return o, nil
+}
+
+// NewEntry returns an entry that delivers a message to a subscriber straight away
+func NewEntry(sub data.GameSubscription, msg Message, origin Origin) OutboxEntry {
+	return OutboxEntry{
+		Subscription: sub,
+		Message:      msg,
+		Origin:       origin,
+		NextAttempt:  time.Now(),
+	}
+}
+
+// NewDigestEntry returns an entry that holds a message until deliverAt, when
+// it is delivered in a single digest together with everything else held for
+// the subscriber
+func NewDigestEntry(sub data.GameSubscription, msg Message, origin Origin, deliverAt time.Time) OutboxEntry {
+	// Buttons on a session from hours ago are no use
+	msg.Actions = nil
+
+	return OutboxEntry{
+		Subscription: sub,
+		Message:      msg,
+		Origin:       origin,
+		NextAttempt:  deliverAt,
+		Digest:       true,
+	}
+}
+
+// Enqueue queues a message for delivery to a subscriber
+func (o *Outbox) Enqueue(sub data.GameSubscription, msg Message, origin Origin) error {
+	return o.EnqueueAll([]OutboxEntry{NewEntry(sub, msg, origin)})
+}
+
+// EnqueueDigest queues a message to be delivered in the subscriber's digest
+func (o *Outbox) EnqueueDigest(sub data.GameSubscription, msg Message, origin Origin, deliverAt time.Time) error {
+	return o.EnqueueAll([]OutboxEntry{NewDigestEntry(sub, msg, origin, deliverAt)})
+}
+
+// EnqueueAll stores new entries, saving the outbox once, and wakes the
+// delivery loop
+func (o *Outbox) EnqueueAll(entries []OutboxEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var held []data.HistoryEntry
+	for n := range entries {
+		entries[n].ID = randomHex(8)
+		entries[n].CreatedAt = time.Now()
+		if entries[n].Digest {
+			reason := fmt.Sprintf("quiet hours until %s", entries[n].NextAttempt.Format(time.DateTime))
+			held = append(held, historyEntry(entries[n], data.OutcomeHeld, reason))
+		}
+	}
+	o.record(held...)
+
+	o.mutex.Lock()
+	o.pending = append(o.pending, entries...)
+	err := o.saveToFile()
+	o.mutex.Unlock()
+
+	o.signal()
+	return err
+}
+
+// Start begins delivering queued notifications in the background
+func (o *Outbox) Start() {
+	go o.run()
+}
+
+// Stop stops the delivery loop, leaving undelivered entries on disk
+func (o *Outbox) Stop() {
+	close(o.stop)
+	<-o.done
+}
+
+// Stats returns a snapshot of the delivery state of a guild's notifications
+func (o *Outbox) Stats(guildID string) OutboxStats {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	stats := OutboxStats{Delivered: o.delivered[guildID]}
+	for _, entry := range o.pending {
+		if entry.Origin.GuildID == guildID {
+			stats.Pending = append(stats.Pending, entry)
+		}
+	}
+	for _, entry := range o.deadLetter {
+		if entry.Origin.GuildID == guildID {
+			stats.DeadLetter = append(stats.DeadLetter, entry)
+		}
+	}
+	return stats
+}
+
+// ForUser returns the notifications for a user that are waiting to be
+// delivered or were given up on
+func (o *Outbox) ForUser(userID string) []OutboxEntry {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	var entries []OutboxEntry
+	for _, queue := range [][]OutboxEntry{o.pending, o.deadLetter} {
+		for _, entry := range queue {
+			if entry.Subscription.UserID == userID {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
+
+// ForgetUser drops every notification for a user and takes their name off
+// the ones about sessions they started. It returns how many entries changed.
+func (o *Outbox) ForgetUser(userID string) (int, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	changed := 0
+	forget := func(entries []OutboxEntry) []OutboxEntry {
+		kept := make([]OutboxEntry, 0, len(entries))
+		for _, entry := range entries {
+			if entry.Subscription.UserID == userID {
+				changed++
+				continue
+			}
+			if entry.Origin.InitiatorID == userID {
+				entry.Origin.InitiatorID = ""
+				entry.Origin.InitiatorName = ""
+				changed++
+			}
+			kept = append(kept, entry)
+		}
+		return kept
+	}
+	o.pending = forget(o.pending)
+	o.deadLetter = forget(o.deadLetter)
+	if changed == 0 {
+		return 0, nil
+	}
+	return changed, o.saveToFile()
+}
+
+// signal wakes the delivery loop without blocking
+func (o *Outbox) signal() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run is the delivery loop
+func (o *Outbox) run() {
+	defer close(o.done)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-o.stop:
+			return
+		case <-o.wake:
+		case <-timer.C:
+		}
+
+		o.deliverDue()
+		timer.Reset(o.untilNextAttempt())
+	}
+}
+
+// untilNextAttempt returns how long to sleep before the next entry is due
+func (o *Outbox) untilNextAttempt() time.Duration {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	wait := time.Minute
+	now := time.Now()
+	for _, entry := range o.pending {
+		if d := entry.NextAttempt.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// deliverDue attempts every entry whose retry time has come
+func (o *Outbox) deliverDue() {
+	o.mutex.Lock()
+	now := time.Now()
+	var due []OutboxEntry
+	digests := make(map[string][]OutboxEntry)
+	for _, entry := range o.pending {
+		if entry.NextAttempt.After(now) {
+			continue
+		}
+		if entry.Digest {
+			key := entry.Subscription.DeliveryMethod() + ":" + entry.Subscription.Target()
+			digests[key] = append(digests[key], entry)
+		} else {
+			due = append(due, entry)
+		}
+	}
+	o.mutex.Unlock()
+
+	// Each delivery is a message and the entries it completes
+	type delivery struct {
+		entries []OutboxEntry
+		message Message
+	}
+	var deliveries []delivery
+	for _, entry := range due {
+		deliveries = append(deliveries, delivery{entries: []OutboxEntry{entry}, message: entry.Message})
+	}
+	for _, entries := range digests {
+		deliveries = append(deliveries, delivery{entries: entries, message: digestMessage(entries)})
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	// Sending happens without the lock so Enqueue never waits on the
+	// network, and on a few workers so one slow backend doesn't hold up the rest
+	results := make([]error, len(deliveries))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(o.Workers, len(deliveries)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range next {
+				_, results[n] = o.notifier.Send(deliveries[n].entries[0].Subscription, deliveries[n].message)
+			}
+		}()
+	}
+	for n := range deliveries {
+		next <- n
+	}
+	close(next)
+	wg.Wait()
+
+	// Save and record the whole round at once
+	var history []data.HistoryEntry
+	o.mutex.Lock()
+	for n, d := range deliveries {
+		for _, entry := range d.entries {
+			if attempt, found := o.complete(entry.ID, results[n]); found {
+				history = append(history, attempt)
+			}
+		}
+	}
+	err := o.saveToFile()
+	o.mutex.Unlock()
+
+	if err != nil {
+		log.Printf("Error saving outbox: %v", err)
+	}
+	o.record(history...)
+}
+
+// digestMessage combines held notifications into a single message
+func digestMessage(entries []OutboxEntry) Message {
+	if len(entries) == 1 {
+		return entries[0].Message
+	}
+
+	var body strings.Builder
+	for _, entry := range entries {
+		body.WriteString(fmt.Sprintf("• %s\n", entry.Message.Title))
+	}
+
+	return Message{
+		Title: fmt.Sprintf("☀️ %d LFG sessions while you were away", len(entries)),
+		Body:  strings.TrimSpace(body.String()),
+		Tags:  []string{"sunrise"},
+	}
+}
+
+// complete updates an entry with the outcome of a delivery attempt and
+// returns the attempt for the history log. The caller saves the outbox. Must
+// hold the lock.
+func (o *Outbox) complete(id string, sendErr error) (data.HistoryEntry, bool) {
+	for i := range o.pending {
+		if o.pending[i].ID != id {
+			continue
+		}
+		entry := &o.pending[i]
+		entry.Attempts++
+
+		var attempt data.HistoryEntry
+		switch {
+		case sendErr == nil:
+			o.delivered[entry.Origin.GuildID]++
+			attempt = historyEntry(*entry, data.OutcomeSent, "")
+			o.pending = append(o.pending[:i], o.pending[i+1:]...)
+		case entry.Attempts >= o.MaxAttempts:
+			entry.LastError = sendErr.Error()
+			attempt = historyEntry(*entry, data.OutcomeFailed, entry.LastError)
+			log.Printf("Giving up on notification to %s after %d attempts: %v", entry.Subscription.Username, entry.Attempts, sendErr)
+			o.deadLetter = append(o.deadLetter, *entry)
+			if len(o.deadLetter) > maxDeadLetters {
+				o.deadLetter = o.deadLetter[len(o.deadLetter)-maxDeadLetters:]
+			}
+			o.pending = append(o.pending[:i], o.pending[i+1:]...)
+		default:
+			entry.LastError = sendErr.Error()
+			entry.NextAttempt = time.Now().Add(o.backoff(entry.Attempts))
+			attempt = historyEntry(*entry, data.OutcomeRetrying, entry.LastError)
+			log.Printf("Error notifying %s (attempt %d/%d, retrying at %s): %v",
+				entry.Subscription.Username, entry.Attempts, o.MaxAttempts, entry.NextAttempt.Format(time.Kitchen), sendErr)
+		}
+		return attempt, true
+	}
+	return data.HistoryEntry{}, false
+}
+
+// record adds delivery attempts to the history log, if there is one
+func (o *Outbox) record(attempts ...data.HistoryEntry) {
+	if o.History == nil || len(attempts) == 0 {
+		return
+	}
+	if err := o.History.Record(attempts...); err != nil {
+		log.Printf("Error recording notification history: %v", err)
+	}
+}
+
+// historyEntry describes an attempt to deliver an entry for the history log
+func historyEntry(entry OutboxEntry, outcome, reason string) data.HistoryEntry {
+	return data.HistoryEntry{
+		Time:          time.Now(),
+		GuildID:       entry.Origin.GuildID,
+		UserID:        entry.Subscription.UserID,
+		Username:      entry.Subscription.Username,
+		Game:          entry.Subscription.Game,
+		InitiatorID:   entry.Origin.InitiatorID,
+		InitiatorName: entry.Origin.InitiatorName,
+		Backend:       entry.Subscription.DeliveryMethod(),
+		Outcome:       outcome,
+		Reason:        reason,
+	}
+}
+
+// backoff returns the delay before the next attempt: exponential in the
+// number of attempts so far, capped at MaxDelay, plus up to 50% jitter
+func (o *Outbox) backoff(attempts int) time.Duration {
+	delay := o.BaseDelay
+	for i := 1; i < attempts && delay < o.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > o.MaxDelay {
+		delay = o.MaxDelay
+	}
+	return delay + time.Duration(mathrand.Int63n(int64(delay)/2+1))
+}
+
+// saveToFile saves the outbox to its JSON file
+func (o *Outbox) saveToFile() error {
+	contents, err := json.MarshalIndent(outboxFile{
+		Pending:    withoutCallbacks(o.pending),
+		DeadLetter: withoutCallbacks(o.deadLetter),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return data.WriteFileAtomic(o.filePath, contents, 0644)
+}
+
+// withoutCallbacks copies entries without their callback actions. The
+// callback tokens only mean something to this run, so they are never saved.
+func withoutCallbacks(entries []OutboxEntry) []OutboxEntry {
+	stripped := make([]OutboxEntry, len(entries))
+	for i, entry := range entries {
+		entry.Message.Actions = slices.DeleteFunc(slices.Clone(entry.Message.Actions), isCallbackAction)
+		stripped[i] = entry
+	}
+	return stripped
+}
+
+// loadFromFile loads the outbox from its JSON file
+func (o *Outbox) loadFromFile() error {
+	contents, err := os.ReadFile(o.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Nothing queued yet, that's okay
+			return nil
+		}
+		return err
+	}
+
+	var file outboxFile
+	if err := json.Unmarshal(contents, &file); err != nil {
+		return err
+	}
+	if file.Pending != nil {
+		o.pending = file.Pending
+	}
+
+	// Callbacks only live in memory, so buttons from before a restart would
+	// just fail. Files written before they were left out may still have them.
+	o.pending = withoutCallbacks(o.pending)
+	if file.DeadLetter != nil {
+		o.deadLetter = file.DeadLetter
+	}
+	return nil
+}
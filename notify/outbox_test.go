@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"discord-bot/data"
+)
+
+func TestOutboxStatsPerGuild(t *testing.T) {
+	outbox, err := NewOutbox(filepath.Join(t.TempDir(), "outbox.json"), NewDispatcher(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, guildID := range []string{"guild-a", "guild-a", "guild-b"} {
+		sub := data.GameSubscription{GuildID: guildID, UserID: "1", Game: "valorant"}
+		if err := outbox.Enqueue(sub, Message{Title: "LFG"}, Origin{GuildID: guildID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for guildID, want := range map[string]int{"guild-a": 2, "guild-b": 1, "guild-c": 0} {
+		pending := outbox.Stats(guildID).Pending
+		if len(pending) != want {
+			t.Errorf("%s: got %d pending, want %d", guildID, len(pending), want)
+		}
+		for _, entry := range pending {
+			if entry.Origin.GuildID != guildID {
+				t.Errorf("%s: stats include an entry from %s", guildID, entry.Origin.GuildID)
+			}
+		}
+	}
+}
+
+// slowNotifier counts how many sends are in flight at once
+type slowNotifier struct {
+	mutex    sync.Mutex
+	inFlight int
+	most     int
+	sent     int
+}
+
+func (n *slowNotifier) Send(target string, msg Message) (int, error) {
+	n.mutex.Lock()
+	n.inFlight++
+	n.most = max(n.most, n.inFlight)
+	n.mutex.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	n.mutex.Lock()
+	n.inFlight--
+	n.sent++
+	n.mutex.Unlock()
+	return 200, nil
+}
+
+func TestOutboxDeliversWithBoundedWorkers(t *testing.T) {
+	notifier := &slowNotifier{}
+	dispatcher := NewDispatcher()
+	dispatcher.Register(data.DeliveryNTFY, notifier)
+	outbox, err := NewOutbox(filepath.Join(t.TempDir(), "outbox.json"), dispatcher, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outbox.Workers = 4
+
+	var entries []OutboxEntry
+	for n := range 20 {
+		sub := data.GameSubscription{UserID: fmt.Sprint(n), Game: "valorant", Delivery: data.DeliveryNTFY, NTFYTopic: fmt.Sprint("topic-", n)}
+		entries = append(entries, NewEntry(sub, Message{Title: "LFG"}, Origin{}))
+	}
+	if err := outbox.EnqueueAll(entries); err != nil {
+		t.Fatal(err)
+	}
+
+	outbox.Start()
+	defer outbox.Stop()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(outbox.Stats("").Pending) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d notification(s) never delivered", len(outbox.Stats("").Pending))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	notifier.mutex.Lock()
+	defer notifier.mutex.Unlock()
+	if notifier.sent != len(entries) {
+		t.Errorf("sent %d, want %d", notifier.sent, len(entries))
+	}
+	if notifier.most > outbox.Workers {
+		t.Errorf("%d sends at once, want at most %d", notifier.most, outbox.Workers)
+	}
+	if notifier.most < 2 {
+		t.Errorf("sends weren't concurrent")
+	}
+}
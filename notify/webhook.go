@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// nonPublicPrefixes are ranges that aren't reachable on the internet but
+// aren't covered by the netip.Addr checks in IsPublicAddr
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "This" network
+	netip.MustParsePrefix("100.64.0.0/10"),  // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // Reserved, including broadcast
+	netip.MustParsePrefix("64:ff9b:1::/48"), // Local-use NAT64
+	netip.MustParsePrefix("2001:db8::/32"),  // Documentation
+}
+
+// IsPublicAddr reports whether an address is on the public internet, so
+// webhooks can't be pointed at the bot's own network
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateWebhookURL checks a webhook URL is HTTPS and points at a public host
+func ValidateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("webhook delivery needs a valid `https://` `webhook-url`")
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		strings.HasSuffix(host, ".local") || strings.HasSuffix(host, ".internal") {
+		return fmt.Errorf("webhook URLs must point at a public host")
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !IsPublicAddr(addr) {
+			return fmt.Errorf("webhook URLs must point at a public host")
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("couldn't look up webhook host %s", host)
+	}
+	for _, addr := range addrs {
+		if !IsPublicAddr(addr) {
+			return fmt.Errorf("webhook URLs must point at a public host")
+		}
+	}
+	return nil
+}
+
+// publicOnlyControl refuses connections to non-public addresses. It runs
+// after DNS resolution, so it also catches hosts that resolve somewhere
+// else than when the URL was saved, and redirects.
+func publicOnlyControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !IsPublicAddr(addr) {
+		return fmt.Errorf("refusing to connect to non-public address %s", addr)
+	}
+	return nil
+}
+
+// Webhook delivers notifications as a JSON POST to an HTTPS endpoint
+type Webhook struct {
+	Client *http.Client
+}
+
+// NewWebhook creates a new webhook backend that only connects to public addresses
+func NewWebhook() *Webhook {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: publicOnlyControl,
+	}
+	return &Webhook{
+		Client: &http.Client{
+			Timeout: 10 * time.Second,
+			// No proxy, it would be the one dialed and the check would be pointless
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 5 * time.Second,
+				MaxIdleConns:        10,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+	}
+}
+
+// Send posts the message as JSON to the webhook URL
+func (w *Webhook) Send(webhookURL string, msg Message) (int, error) {
+	if webhookURL == "" {
+		return 0, fmt.Errorf("no webhook URL set")
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := w.Client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("error calling webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}